@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/auth"
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const accessTokenTTL = time.Hour
+
+// dbDenylistStore keeps revoked access tokens in Postgres for auth.Denylist.
+type dbDenylistStore struct {
+	db *database.Queries
+}
+
+func (s dbDenylistStore) Deny(ctx context.Context, jti string, expiresAt time.Time) error {
+	return s.db.RevokeAccessToken(ctx, database.RevokeAccessTokenParams{
+		Jti:       jti,
+		ExpiresAt: expiresAt,
+	})
+}
+
+func (s dbDenylistStore) Lookup(ctx context.Context, jti string) (time.Time, bool, error) {
+	revoked, err := s.db.GetRevokedAccessToken(ctx, jti)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return revoked.ExpiresAt, true, nil
+}
+
+func (cfg *apiConfig) makeAccessToken(user *database.User) (string, error) {
+	return cfg.jwtKeys.MakeAccessToken(user.ID, user.TokenVersion, accessTokenTTL)
+}
+
+// revokeAccessTokens invalidates every access token issued to the user so
+// far. Refresh tokens are left alone; callers revoke those when they should.
+func revokeAccessTokens(ctx context.Context, q *database.Queries, userID uuid.UUID) error {
+	_, err := q.IncrementUserTokenVersion(ctx, userID)
+	return err
+}
+
+// handleLogout revokes the access token it was called with and, when the
+// body carries one, the refresh token of the same session.
+func (cfg *apiConfig) handleLogout(w http.ResponseWriter, r *http.Request, token string, user *database.User) {
+	type logoutReqBody struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	var logoutReq logoutReqBody
+	json.NewDecoder(r.Body).Decode(&logoutReq)
+
+	claims, err := cfg.jwtKeys.ParseAccessToken(token)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err := cfg.denylist.Deny(r.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	if logoutReq.RefreshToken != "" {
+		rtdb, err := cfg.db.GetRefreshToken(r.Context(), auth.HashToken(logoutReq.RefreshToken))
+		if err == nil && rtdb.UserID == user.ID {
+			err = cfg.db.RevokeRefreshTokenFamily(r.Context(), rtdb.FamilyID)
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			responseWithJsonError(w, err.Error(), 500)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRevokeAllTokens signs the user out everywhere: every access token,
+// refresh token and personal access token stops working.
+func (cfg *apiConfig) handleRevokeAllTokens(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		if err := revokeAccessTokens(r.Context(), q, user.ID); err != nil {
+			return err
+		}
+		if err := q.DeleteUserPersonalAccessTokens(r.Context(), user.ID); err != nil {
+			return err
+		}
+		return q.RevokeUserRefreshTokens(r.Context(), user.ID)
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/auth"
+	"github.com/ZDSDD/Chirpy/internal/database"
+)
+
+const (
+	accountDeletionGracePeriod = 30 * 24 * time.Hour
+	purgeInterval              = time.Hour
+)
+
+// handleDeleteMe deletes the current user. Chirps, tokens, likes, follows and
+// the rest go with the users row through ON DELETE CASCADE.
+//
+// With grace_period set the account is only deactivated and purged once
+// accountDeletionGracePeriod has passed; logging in before then restores it.
+// Meanwhile its chirps, likes and follows are left out of what others see.
+func (cfg *apiConfig) handleDeleteMe(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	type deleteReqBody struct {
+		Password    string `json:"password"`
+		GracePeriod bool   `json:"grace_period"`
+	}
+	var deleteReq deleteReqBody
+	json.NewDecoder(r.Body).Decode(&deleteReq)
+	if deleteReq.Password == "" {
+		responseWithJsonError(w, "Password is required to delete your account", 400)
+		return
+	}
+	if err := auth.CheckPasswordHash(deleteReq.Password, user.HashedPassword); err != nil {
+		responseWithJsonError(w, "Password is incorrect", 403)
+		return
+	}
+	if !deleteReq.GracePeriod {
+		if err := cfg.db.DeleteUser(r.Context(), user.ID); err != nil {
+			responseWithJsonError(w, err.Error(), 500)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		if err := q.ScheduleUserDeletion(r.Context(), user.ID); err != nil {
+			return err
+		}
+		if err := revokeAccessTokens(r.Context(), q, user.ID); err != nil {
+			return err
+		}
+		return q.RevokeUserRefreshTokens(r.Context(), user.ID)
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	responseWithJson(map[string]time.Time{
+		"purge_after": time.Now().Add(accountDeletionGracePeriod),
+	}, w, http.StatusAccepted)
+}
+
+// purgeExpired removes accounts whose deletion grace period has run out and
+// revoked access tokens and MFA challenges that have expired anyway, every
+// interval until ctx is done.
+func (cfg *apiConfig) purgeExpired(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		deletedBefore := sql.NullTime{Time: time.Now().Add(-accountDeletionGracePeriod), Valid: true}
+		n, err := cfg.db.PurgeDeletedUsers(ctx, deletedBefore)
+		if err != nil {
+			log.Printf("Error purging deleted users: %s", err)
+		} else if n > 0 {
+			log.Printf("Purged %d deleted users", n)
+		}
+		if _, err := cfg.db.DeleteExpiredRevokedAccessTokens(ctx); err != nil {
+			log.Printf("Error purging revoked access tokens: %s", err)
+		}
+		if _, err := cfg.db.DeleteExpiredMFAChallenges(ctx); err != nil {
+			log.Printf("Error purging MFA challenges: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
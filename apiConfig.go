@@ -1,17 +1,30 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"sync/atomic"
 
+	"github.com/ZDSDD/Chirpy/internal/auth"
 	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/ZDSDD/Chirpy/internal/mailer"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
 	db             *database.Queries
-	jwtSecret      string
+	dbConn         *sql.DB
+	jwtKeys        *auth.KeySet
+	denylist       *auth.Denylist
+	totpSecrets    *auth.SecretBox
+	mailer         mailer.Mailer
+	loginAccounts  *auth.Limiter
+	loginIPs       *auth.Limiter
+	resetEmails    *auth.Limiter
+	resetIPs       *auth.Limiter
+	passwordPolicy auth.PasswordPolicy
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -21,6 +34,20 @@ func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
 	})
 }
 
+// withTx runs fn against queries bound to a single transaction, committing
+// only if fn returns nil.
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(cfg.db.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 func (cfg *apiConfig) handleReset(rw http.ResponseWriter, r *http.Request) {
 	platform := getEnvVariable("PLATFORM")
 	if platform != "dev" {
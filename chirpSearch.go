@@ -0,0 +1,96 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// searchCursor points at the last result of a search page. Results are
+// ordered by (rank, id) rather than by creation time.
+type searchCursor struct {
+	Rank float32   `json:"rank"`
+	ID   uuid.UUID `json:"id"`
+}
+
+func (cfg *apiConfig) handleSearchChirps(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		responseWithJsonError(w, "Query is required", 400)
+		return
+	}
+	limit, err := parsePageLimit(r)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 400)
+		return
+	}
+	// Search results are keyed by rank, so the cursor has its own shape.
+	page := pageRequest{Limit: limit}
+	params := database.SearchChirpsParams{
+		Query:     query,
+		PageLimit: page.fetchLimit(),
+	}
+	if raw := r.URL.Query().Get("cursor"); raw != "" {
+		var cursor searchCursor
+		if err := decodeCursor(raw, &cursor); err != nil {
+			responseWithJsonError(w, err.Error(), 400)
+			return
+		}
+		params.CursorRank = sql.NullFloat64{Float64: float64(cursor.Rank), Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	if author_id := r.URL.Query().Get("author_id"); author_id != "" {
+		authorID, err := uuid.Parse(author_id)
+		if err != nil {
+			responseWithJsonError(w, "Invalid author ID", 400)
+			return
+		}
+		params.AuthorID = uuid.NullUUID{UUID: authorID, Valid: true}
+	}
+	if params.CreatedAfter, err = parseTimeParam(r, "since"); err != nil {
+		responseWithJsonError(w, "Invalid since, expected RFC 3339 timestamp", 400)
+		return
+	}
+	if params.CreatedBefore, err = parseTimeParam(r, "until"); err != nil {
+		responseWithJsonError(w, "Invalid until, expected RFC 3339 timestamp", 400)
+		return
+	}
+
+	rows, err := cfg.db.SearchChirps(r.Context(), params)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	rows, nextCursor := trimPage(rows, page.Limit, func(row database.SearchChirpsRow) any {
+		return searchCursor{Rank: row.Rank, ID: row.Chirp.ID}
+	})
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	chirpsResponse := mapChirpsToResponse(chirps)
+	if err := cfg.decorateChirps(r, chirpsResponse); err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	responseWithJson(pageResponse[chirpResponse]{
+		Data:       chirpsResponse,
+		NextCursor: nextCursor,
+	}, w, http.StatusOK)
+}
+
+func parseTimeParam(r *http.Request, key string) (sql.NullTime, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
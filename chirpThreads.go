@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/ZDSDD/Chirpy/internal/database"
+)
+
+type chirpThreadResponse struct {
+	Ancestors  []chirpResponse `json:"ancestors"`
+	Chirp      chirpResponse   `json:"chirp"`
+	Replies    []chirpResponse `json:"replies"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// handleGetChirpThread returns the chain of chirps the given chirp replies to,
+// root first, followed by a page of every reply beneath it in creation order.
+func (cfg *apiConfig) handleGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	chirp, ok := cfg.loadPathChirp(w, r)
+	if !ok {
+		return
+	}
+	page, err := parsePageRequest(r)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 400)
+		return
+	}
+	ancestors, err := cfg.db.GetChirpAncestors(r.Context(), chirp.ID)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	replies, err := cfg.db.GetChirpDescendants(r.Context(), database.GetChirpDescendantsParams{
+		ChirpID:         chirp.ID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	replies, nextCursor := trimPage(replies, page.Limit, chirpCursor)
+	thread := chirpThreadResponse{
+		Ancestors:  mapChirpsToResponse(ancestors),
+		Chirp:      mapChirpToResponse(&chirp),
+		Replies:    mapChirpsToResponse(replies),
+		NextCursor: nextCursor,
+	}
+	root := []chirpResponse{thread.Chirp}
+	if err := cfg.decorateChirps(r, thread.Ancestors, root, thread.Replies); err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	thread.Chirp = root[0]
+	responseWithJson(thread, w, http.StatusOK)
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -17,6 +20,25 @@ type Chirp struct {
 	Body string `json:"body"`
 }
 
+const maxChirpLength = 140
+
+var profaneWords = []string{"kerfuffle", "sharbert", "fornax"}
+
+var (
+	errChirpTooLong       = errors.New("Chirp is too long")
+	errChirpForbidden     = errors.New("Forbidden")
+	errRechirpNotEditable = errors.New("Rechirps cannot be edited")
+)
+
+// cleanChirpBody applies the length and profanity rules of validateChirp,
+// which edits to a chirp have to pass.
+func cleanChirpBody(body string) (string, error) {
+	if len(body) > maxChirpLength {
+		return "", errChirpTooLong
+	}
+	return cleanProfaneWords(body, profaneWords), nil
+}
+
 func cleanProfaneWords(s string, profaneWords []string) string {
 	words := strings.Fields(s)
 	var sb strings.Builder
@@ -45,20 +67,36 @@ func validateChirp(w http.ResponseWriter, r *http.Request) {
 		responseWithJsonError(w, errorMsg, 500)
 		return
 	}
-	if len(chirp.Body) > 140 {
-		responseWithJsonError(w, "Chirp is too long", 400)
+	cleanedBody, err := cleanChirpBody(chirp.Body)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 400)
 		return
 	}
 	responseWithJson(struct {
 		CleanedBody string `json:"cleaned_body"`
-	}{CleanedBody: cleanProfaneWords(chirp.Body, []string{"kerfuffle", "sharbert", "fornax"})}, w, 200)
+	}{CleanedBody: cleanedBody}, w, 200)
 }
 
 func (cfg *apiConfig) handleGetChirp(w http.ResponseWriter, r *http.Request) {
+	chirp, ok := cfg.loadPathChirp(w, r)
+	if !ok {
+		return
+	}
+	chirpsResponse := []chirpResponse{mapChirpToResponse(&chirp)}
+	if err := cfg.decorateChirps(r, chirpsResponse); err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	responseWithJson(chirpsResponse[0], w, http.StatusOK)
+}
+
+// loadPathChirp resolves the {chirpID} path value to an existing chirp,
+// writing the error response itself when it cannot.
+func (cfg *apiConfig) loadPathChirp(w http.ResponseWriter, r *http.Request) (database.Chirp, bool) {
 	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
 	if err != nil {
 		responseWithJsonError(w, "Invalid chirp ID", 400)
-		return
+		return database.Chirp{}, false
 	}
 	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
 	if err != nil {
@@ -67,69 +105,155 @@ func (cfg *apiConfig) handleGetChirp(w http.ResponseWriter, r *http.Request) {
 		} else {
 			responseWithJsonError(w, err.Error(), 500)
 		}
-		return
+		return database.Chirp{}, false
 	}
-	responseWithJson(mapChirpToResponse(&chirp), w, http.StatusOK)
+	return chirp, true
 }
 
 func (cfg *apiConfig) handleGetChirps(w http.ResponseWriter, r *http.Request) {
-	author_id := r.URL.Query().Get("author_id")
-	sortOrder := r.URL.Query().Get("sort") // Get sorting order from query params
-	// Default sorting order is "asc"
-	sortOrder = strings.ToLower(sortOrder)
-	if sortOrder != "desc" {
-		sortOrder = "asc"
+	sortOrder := parseSortOrder(r)
+	page, err := parsePageRequest(r)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 400)
+		return
 	}
-	var chirps []database.Chirp
-	var err error
-	if author_id != "" {
-		authorID, err := uuid.Parse(author_id)
+	var authorID uuid.NullUUID
+	if author_id := r.URL.Query().Get("author_id"); author_id != "" {
+		id, err := uuid.Parse(author_id)
 		if err != nil {
 			responseWithJsonError(w, "Invalid author ID", 400)
 			return
 		}
-		chirps, err = cfg.db.GetChirpsByUser(r.Context(), database.GetChirpsByUserParams{
-			UserID:  authorID,
-			Column2: sortOrder,
-		})
-		if err != nil {
-			responseWithJsonError(w, err.Error(), 500)
-			return
-		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	params := database.GetChirpsPageAscParams{
+		AuthorID:        authorID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	}
+	var chirps []database.Chirp
+	if sortOrder == "desc" {
+		chirps, err = cfg.db.GetChirpsPageDesc(r.Context(), database.GetChirpsPageDescParams(params))
 	} else {
-		chirps, err = cfg.db.GetChirps(r.Context(), sortOrder)
-		if err != nil {
-			responseWithJsonError(w, err.Error(), 500)
-			return
-		}
+		chirps, err = cfg.db.GetChirpsPageAsc(r.Context(), params)
 	}
-	var chirpsResponse []chirpResponse
-	for _, chirp := range chirps {
-		chirpsResponse = append(chirpsResponse, mapChirpToResponse(&chirp))
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
 	}
-	responseWithJson(chirpsResponse, w, http.StatusOK)
+	chirps, nextCursor := trimPage(chirps, page.Limit, chirpCursor)
+	chirpsResponse := mapChirpsToResponse(chirps)
+	if err := cfg.decorateChirps(r, chirpsResponse); err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	responseWithJson(pageResponse[chirpResponse]{
+		Data:       chirpsResponse,
+		NextCursor: nextCursor,
+	}, w, http.StatusOK)
 }
 
 func (cfg *apiConfig) handleCreateChirp(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	if !user.EmailVerifiedAt.Valid {
+		responseWithJsonError(w, "Verify your email address before posting", 403)
+		return
+	}
 	type jsonPayload struct {
-		Body string `json:"body"`
+		Body      string     `json:"body"`
+		InReplyTo *uuid.UUID `json:"in_reply_to"`
+		RechirpOf *uuid.UUID `json:"rechirp_of"`
+		QuoteOf   *uuid.UUID `json:"quote_of"`
 	}
 	jp := jsonPayload{}
 	json.NewDecoder(r.Body).Decode(&jp)
+	if jp.RechirpOf != nil {
+		if jp.Body != "" || jp.InReplyTo != nil || jp.QuoteOf != nil {
+			responseWithJsonError(w, "A rechirp cannot have a body, reply to or quote a chirp", 400)
+			return
+		}
+		cfg.createRechirp(w, r, user, *jp.RechirpOf)
+		return
+	}
 	if jp.Body == "" {
 		responseWithJsonError(w, "Body is required", 400)
 		return
 	}
-
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
+	params := database.CreateChirpParams{
 		Body:   jp.Body,
 		UserID: user.ID,
+	}
+	if jp.InReplyTo != nil {
+		parent, err := cfg.db.GetChirp(r.Context(), *jp.InReplyTo)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				responseWithJsonError(w, "Chirp being replied to not found", 404)
+			} else {
+				responseWithJsonError(w, err.Error(), 500)
+			}
+			return
+		}
+		params.InReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+	if jp.QuoteOf != nil {
+		original, err := cfg.resolveOriginal(r.Context(), *jp.QuoteOf)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				responseWithJsonError(w, "Chirp being quoted not found", 404)
+			} else {
+				responseWithJsonError(w, err.Error(), 500)
+			}
+			return
+		}
+		params.QuoteOf = uuid.NullUUID{UUID: original.ID, Valid: true}
+		params.IsQuote = true
+	}
+
+	var chirp database.Chirp
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		chirp, err = q.CreateChirp(r.Context(), params)
+		if err != nil {
+			return err
+		}
+		return syncChirpEntities(r.Context(), q, &chirp)
 	})
 	if err != nil {
 		responseWithJsonError(w, err.Error(), 500)
 		return
 	}
-	responseWithJson(mapChirpToResponse(&chirp), w, http.StatusCreated)
+	cfg.respondWithCreatedChirp(w, r, &chirp)
+}
+
+func (cfg *apiConfig) respondWithCreatedChirp(w http.ResponseWriter, r *http.Request, chirp *database.Chirp) {
+	chirpsResponse := []chirpResponse{mapChirpToResponse(chirp)}
+	if err := cfg.decorateChirps(r, chirpsResponse); err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	responseWithJson(chirpsResponse[0], w, http.StatusCreated)
+}
+
+// syncChirpEntities stores the hashtags and mentions parsed from a chirp's
+// body alongside the chirp itself.
+func syncChirpEntities(ctx context.Context, q *database.Queries, chirp *database.Chirp) error {
+	if err := syncChirpHashtags(ctx, q, chirp); err != nil {
+		return err
+	}
+	return syncChirpMentions(ctx, q, chirp)
+}
+
+// decorateChirps fills in the parts of chirp responses that live in other
+// rows: the originals of rechirps and quotes, mentions, and the viewer's
+// likes.
+func (cfg *apiConfig) decorateChirps(r *http.Request, chirps ...[]chirpResponse) error {
+	if err := cfg.attachOriginals(r.Context(), chirps...); err != nil {
+		return err
+	}
+	if err := cfg.attachMentions(r.Context(), chirps...); err != nil {
+		return err
+	}
+	return cfg.markLikedByMe(r, chirps...)
 }
 
 func (cfg *apiConfig) handleDeleteChirp(w http.ResponseWriter, r *http.Request, token string, user *database.User) {
@@ -155,20 +279,170 @@ func (cfg *apiConfig) handleDeleteChirp(w http.ResponseWriter, r *http.Request,
 	w.WriteHeader(http.StatusNoContent)
 }
 
-type chirpResponse struct {
+func (cfg *apiConfig) handleUpdateChirp(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	if !user.EmailVerifiedAt.Valid {
+		responseWithJsonError(w, "Verify your email address before editing chirps", 403)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		responseWithJsonError(w, "Invalid chirp ID", 400)
+		return
+	}
+	type jsonPayload struct {
+		Body string `json:"body"`
+	}
+	jp := jsonPayload{}
+	json.NewDecoder(r.Body).Decode(&jp)
+	if jp.Body == "" {
+		responseWithJsonError(w, "Body is required", 400)
+		return
+	}
+	body, err := cleanChirpBody(jp.Body)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 400)
+		return
+	}
+
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		chirp, err = q.GetChirpForUpdate(r.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+		if chirp.UserID != user.ID {
+			return errChirpForbidden
+		}
+		if chirp.RechirpOf.Valid {
+			return errRechirpNotEditable
+		}
+		if chirp.Body == body {
+			return nil
+		}
+		// Keep the body being replaced so the edit history stays complete.
+		_, err = q.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+			ChirpID: chirp.ID,
+			Body:    chirp.Body,
+		})
+		if err != nil {
+			return err
+		}
+		chirp, err = q.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+			Body: body,
+			ID:   chirp.ID,
+		})
+		if err != nil {
+			return err
+		}
+		return syncChirpEntities(r.Context(), q, &chirp)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			responseWithJsonError(w, "Chirp not found", 404)
+		case errors.Is(err, errChirpForbidden):
+			responseWithJsonError(w, err.Error(), 403)
+		case errors.Is(err, errRechirpNotEditable):
+			responseWithJsonError(w, err.Error(), 400)
+		default:
+			responseWithJsonError(w, err.Error(), 500)
+		}
+		return
+	}
+	responseWithJson(mapChirpToResponse(&chirp), w, http.StatusOK)
+}
+
+func (cfg *apiConfig) handleGetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		responseWithJsonError(w, "Invalid chirp ID", 400)
+		return
+	}
+	if _, err := cfg.db.GetChirp(r.Context(), chirpID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			responseWithJsonError(w, "Chirp not found", 404)
+		} else {
+			responseWithJsonError(w, err.Error(), 500)
+		}
+		return
+	}
+	revisions, err := cfg.db.GetChirpRevisions(r.Context(), chirpID)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	revisionsResponse := make([]chirpRevisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		revisionsResponse = append(revisionsResponse, chirpRevisionResponse{
+			ID:        revision.ID,
+			ChirpID:   revision.ChirpID,
+			Body:      revision.Body,
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+	responseWithJson(revisionsResponse, w, http.StatusOK)
+}
+
+type chirpRevisionResponse struct {
 	ID        uuid.UUID `json:"id"`
+	ChirpID   uuid.UUID `json:"chirp_id"`
 	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type chirpResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Body        string     `json:"body"`
+	UserID      uuid.UUID  `json:"user_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Edited      bool       `json:"edited"`
+	InReplyToID *uuid.UUID `json:"in_reply_to_id"`
+	ReplyCount  int32      `json:"reply_count"`
+	LikeCount   int32      `json:"like_count"`
+	LikedByMe   *bool      `json:"liked_by_me,omitempty"`
+
+	Mentions []mentionResponse `json:"mentions,omitempty"`
+
+	RechirpOf *embeddedChirpResponse `json:"rechirp_of,omitempty"`
+	QuoteOf   *embeddedChirpResponse `json:"quote_of,omitempty"`
+
+	rechirpOfID uuid.NullUUID
+	quoteOfID   uuid.NullUUID
 }
 
 func mapChirpToResponse(dc *database.Chirp) chirpResponse {
-	return chirpResponse{
-		ID:        dc.ID,
-		Body:      dc.Body,
-		UserID:    dc.UserID,
-		CreatedAt: dc.CreatedAt,
-		UpdatedAt: dc.UpdatedAt,
+	resp := chirpResponse{
+		ID:         dc.ID,
+		Body:       dc.Body,
+		UserID:     dc.UserID,
+		CreatedAt:  dc.CreatedAt,
+		UpdatedAt:  dc.UpdatedAt,
+		Edited:     dc.EditedAt.Valid,
+		ReplyCount: dc.ReplyCount,
+		LikeCount:  dc.LikeCount,
+
+		rechirpOfID: dc.RechirpOf,
+		quoteOfID:   dc.QuoteOf,
 	}
+	if dc.InReplyTo.Valid {
+		resp.InReplyToID = &dc.InReplyTo.UUID
+	}
+	if dc.IsQuote && !dc.QuoteOf.Valid {
+		// The quoted chirp was deleted; the quote stays up with a tombstone.
+		resp.QuoteOf = &embeddedChirpResponse{Deleted: true}
+	}
+	return resp
+}
+
+func mapChirpsToResponse(chirps []database.Chirp) []chirpResponse {
+	chirpsResponse := make([]chirpResponse, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpsResponse = append(chirpsResponse, mapChirpToResponse(&chirp))
+	}
+	return chirpsResponse
+}
+
+func chirpCursor(chirp database.Chirp) any {
+	return pageCursor{CreatedAt: chirp.CreatedAt, ID: chirp.ID}
 }
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/auth"
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestHandleGetChirp(t *testing.T) {
+	chirp := database.Chirp{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    uuid.New(),
+		Body:      "hello @bob",
+		LikeCount: 1,
+	}
+	viewer := database.User{ID: uuid.New()}
+
+	tests := []struct {
+		name   string
+		viewer bool
+		// revoked bumps the viewer's token version after their token was
+		// issued, as a password change does.
+		revoked bool
+	}{
+		{"anonymous", false, false},
+		{"signed in", true, false},
+		{"revoked token", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB(t)
+			db.on("GetChirp", func([]driver.Value) [][]driver.Value {
+				return [][]driver.Value{chirpRow(chirp)}
+			})
+			db.on("GetMentionsForChirps", func([]driver.Value) [][]driver.Value {
+				return [][]driver.Value{{chirp.ID.String(), uuid.NewString(), "bob", int64(6), int64(10)}}
+			})
+			db.on("GetLikedChirpIDs", func([]driver.Value) [][]driver.Value {
+				return [][]driver.Value{{chirp.ID.String()}}
+			})
+			db.on("GetRevokedAccessToken", func([]driver.Value) [][]driver.Value {
+				return nil
+			})
+			db.on("GetUserById", func([]driver.Value) [][]driver.Value {
+				current := viewer
+				if tt.revoked {
+					current.TokenVersion++
+				}
+				return [][]driver.Value{userRow(current)}
+			})
+			cfg := db.apiConfig()
+			cfg.jwtKeys = auth.NewHMACKeySet("secret")
+			cfg.denylist = auth.NewDenylist(dbDenylistStore{db: cfg.db})
+
+			req := httptest.NewRequest("GET", "/api/chirps/"+chirp.ID.String(), nil)
+			req.SetPathValue("chirpID", chirp.ID.String())
+			if tt.viewer {
+				token, err := cfg.makeAccessToken(&viewer)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			w := httptest.NewRecorder()
+			cfg.handleGetChirp(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+			}
+			var resp chirpResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.ID != chirp.ID || resp.Body != chirp.Body {
+				t.Errorf("Unexpected chirp %+v", resp)
+			}
+			if len(resp.Mentions) != 1 || resp.Mentions[0].Handle != "bob" {
+				t.Errorf("Expected a mention of bob, got %+v", resp.Mentions)
+			}
+			if tt.viewer && !tt.revoked {
+				if resp.LikedByMe == nil || !*resp.LikedByMe {
+					t.Errorf("Expected liked_by_me to be true, got %v", resp.LikedByMe)
+				}
+			} else if resp.LikedByMe != nil {
+				t.Errorf("Expected no liked_by_me without a valid token, got %v", *resp.LikedByMe)
+			}
+		})
+	}
+}
+
+func TestHandleGetChirpRechirp(t *testing.T) {
+	original := database.Chirp{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    uuid.New(),
+		Body:      "worth repeating",
+	}
+	rechirp := database.Chirp{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    uuid.New(),
+		RechirpOf: uuid.NullUUID{UUID: original.ID, Valid: true},
+	}
+
+	tests := []struct {
+		name          string
+		originalFound bool
+	}{
+		{"original", true},
+		{"original deleted", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB(t)
+			db.on("GetChirp", func([]driver.Value) [][]driver.Value {
+				return [][]driver.Value{chirpRow(rechirp)}
+			})
+			db.on("GetChirpsByIDs", func([]driver.Value) [][]driver.Value {
+				if !tt.originalFound {
+					return nil
+				}
+				return [][]driver.Value{chirpRow(original)}
+			})
+			db.on("GetMentionsForChirps", func([]driver.Value) [][]driver.Value {
+				return nil
+			})
+			cfg := db.apiConfig()
+
+			req := httptest.NewRequest("GET", "/api/chirps/"+rechirp.ID.String(), nil)
+			req.SetPathValue("chirpID", rechirp.ID.String())
+			w := httptest.NewRecorder()
+			cfg.handleGetChirp(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+			}
+			var resp struct {
+				RechirpOf *struct {
+					ID      uuid.UUID `json:"id"`
+					Body    string    `json:"body"`
+					Deleted bool      `json:"deleted"`
+				} `json:"rechirp_of"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.RechirpOf == nil {
+				t.Fatal("Expected the original to be embedded")
+			}
+			if tt.originalFound {
+				if resp.RechirpOf.ID != original.ID || resp.RechirpOf.Body != original.Body {
+					t.Errorf("Expected original %s, got %+v", original.ID, *resp.RechirpOf)
+				}
+			} else if !resp.RechirpOf.Deleted || resp.RechirpOf.ID != uuid.Nil {
+				t.Errorf("Expected a tombstone for the deleted original, got %+v", *resp.RechirpOf)
+			}
+		})
+	}
+}
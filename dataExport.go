@@ -0,0 +1,176 @@
+package main
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const exportBatchSize = 500
+
+// exportSink receives the export one section at a time.
+type exportSink interface {
+	begin(section string) error
+	write(v any) error
+	close() error
+}
+
+// ndjsonSink writes every record as one line tagged with its section.
+type ndjsonSink struct {
+	enc     *json.Encoder
+	section string
+}
+
+func (s *ndjsonSink) begin(section string) error {
+	s.section = section
+	return nil
+}
+
+func (s *ndjsonSink) write(v any) error {
+	return s.enc.Encode(struct {
+		Type string `json:"type"`
+		Data any    `json:"data"`
+	}{s.section, v})
+}
+
+func (s *ndjsonSink) close() error {
+	return nil
+}
+
+// zipSink writes each section to its own NDJSON file inside a ZIP archive.
+type zipSink struct {
+	zw  *zip.Writer
+	enc *json.Encoder
+}
+
+func (s *zipSink) begin(section string) error {
+	f, err := s.zw.Create(section + ".ndjson")
+	if err != nil {
+		return err
+	}
+	s.enc = json.NewEncoder(f)
+	return nil
+}
+
+func (s *zipSink) write(v any) error {
+	return s.enc.Encode(v)
+}
+
+func (s *zipSink) close() error {
+	return s.zw.Close()
+}
+
+type sessionExport struct {
+	SessionID  uuid.UUID  `json:"session_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// handleExportMe streams everything we hold about the current user as NDJSON
+// (the default) or, with ?format=zip, as a ZIP archive. Chirps are read in
+// batches so large accounts are never held in memory at once.
+func (cfg *apiConfig) handleExportMe(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ndjson"
+	}
+	var sink exportSink
+	filename := fmt.Sprintf("chirpy-export-%s.%s", user.ID, format)
+	switch format {
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		sink = &ndjsonSink{enc: json.NewEncoder(w)}
+	case "zip":
+		w.Header().Set("Content-Type", "application/zip")
+		sink = &zipSink{zw: zip.NewWriter(w)}
+	default:
+		responseWithJsonError(w, "format must be ndjson or zip", 400)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	// The status is already sent, so a failure can only cut the stream short.
+	if err := cfg.writeExport(r, sink, user); err != nil {
+		log.Printf("Error exporting data for user %s: %s", user.ID, err)
+		return
+	}
+	if err := sink.close(); err != nil {
+		log.Printf("Error exporting data for user %s: %s", user.ID, err)
+	}
+}
+
+func (cfg *apiConfig) writeExport(r *http.Request, sink exportSink, user *database.User) error {
+	if err := sink.begin("profile"); err != nil {
+		return err
+	}
+	if err := sink.write(mapToJson(user, "", "")); err != nil {
+		return err
+	}
+
+	if err := sink.begin("chirps"); err != nil {
+		return err
+	}
+	params := database.GetChirpsPageAscParams{
+		AuthorID:  uuid.NullUUID{UUID: user.ID, Valid: true},
+		PageLimit: exportBatchSize,
+	}
+	for {
+		chirps, err := cfg.db.GetChirpsPageAsc(r.Context(), params)
+		if err != nil {
+			return err
+		}
+		if len(chirps) == 0 {
+			break
+		}
+		chirpsResponse := mapChirpsToResponse(chirps)
+		if err := cfg.decorateChirps(r, chirpsResponse); err != nil {
+			return err
+		}
+		for _, chirp := range chirpsResponse {
+			if err := sink.write(chirp); err != nil {
+				return err
+			}
+		}
+		last := chirps[len(chirps)-1]
+		params.CursorCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: last.ID, Valid: true}
+	}
+
+	if err := sink.begin("sessions"); err != nil {
+		return err
+	}
+	tokens, err := cfg.db.GetRefreshTokensByUser(r.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		// The token itself is a credential and stays out of the export.
+		session := sessionExport{
+			SessionID:  token.FamilyID,
+			CreatedAt:  token.CreatedAt,
+			LastUsedAt: token.LastUsedAt,
+			UserAgent:  token.UserAgent,
+			IP:         token.Ip,
+			ExpiresAt:  token.ExpiresAt,
+		}
+		if token.RevokedAt.Valid {
+			session.RevokedAt = &token.RevokedAt.Time
+		}
+		if err := sink.write(session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
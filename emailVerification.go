@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/auth"
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/ZDSDD/Chirpy/internal/mailer"
+)
+
+const emailVerificationTTL = 48 * time.Hour
+
+// sendEmailVerification issues a fresh verification token for user, replacing
+// any unused ones, and mails it to the user's current address.
+func (cfg *apiConfig) sendEmailVerification(ctx context.Context, user *database.User) error {
+	token, tokenHash, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+	err = cfg.withTx(ctx, func(q *database.Queries) error {
+		if err := q.DeleteUnusedEmailVerificationTokens(ctx, user.ID); err != nil {
+			return err
+		}
+		_, err := q.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+			TokenHash: tokenHash,
+			UserID:    user.ID,
+			ExpiresAt: time.Now().Add(emailVerificationTTL),
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Chirpy email address",
+		Body: fmt.Sprintf("Welcome to Chirpy, @%s!\n\n"+
+			"Confirm this address by sending the token below to POST /api/users/verify:\n\n%s\n\n"+
+			"The token expires in %s.\n", user.Handle, token, emailVerificationTTL),
+	})
+}
+
+func (cfg *apiConfig) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	type verifyReqBody struct {
+		Token string `json:"token"`
+	}
+	var verifyReq verifyReqBody
+	json.NewDecoder(r.Body).Decode(&verifyReq)
+	if verifyReq.Token == "" {
+		responseWithJsonError(w, "Token is required", 400)
+		return
+	}
+	var user database.User
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		verification, err := q.UseEmailVerificationToken(r.Context(), auth.HashToken(verifyReq.Token))
+		if err != nil {
+			return err
+		}
+		user, err = q.MarkUserEmailVerified(r.Context(), verification.UserID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			responseWithJsonError(w, "Invalid or expired token", 400)
+			return
+		}
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	responseWithJson(mapToJson(&user, "", ""), w, http.StatusOK)
+}
+
+func (cfg *apiConfig) handleResendEmailVerification(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	if user.EmailVerifiedAt.Valid {
+		responseWithJsonError(w, "Email is already verified", 409)
+		return
+	}
+	if err := cfg.sendEmailVerification(r.Context(), user); err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
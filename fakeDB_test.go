@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/ZDSDD/Chirpy/internal/database"
+)
+
+// fakeDB stands in for Postgres in handler tests. Queries are answered by
+// their sqlc name with canned rows; a query the test didn't expect fails it.
+type fakeDB struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	results map[string]func(args []driver.Value) [][]driver.Value
+	calls   map[string]int
+}
+
+func newFakeDB(t *testing.T) *fakeDB {
+	return &fakeDB{
+		t:       t,
+		results: map[string]func([]driver.Value) [][]driver.Value{},
+		calls:   map[string]int{},
+	}
+}
+
+// on answers the named query with rows. For :exec queries the number of
+// rows is what RowsAffected reports.
+func (db *fakeDB) on(name string, rows func(args []driver.Value) [][]driver.Value) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.results[name] = rows
+}
+
+func (db *fakeDB) called(name string) int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.calls[name]
+}
+
+// apiConfig returns a config whose queries go to db.
+func (db *fakeDB) apiConfig() *apiConfig {
+	conn := sql.OpenDB(fakeConnector{db})
+	db.t.Cleanup(func() { conn.Close() })
+	return &apiConfig{db: database.New(conn), dbConn: conn}
+}
+
+var queryName = regexp.MustCompile(`-- name: (\w+)`)
+
+func (db *fakeDB) run(query string, args []driver.Value) ([][]driver.Value, error) {
+	m := queryName.FindStringSubmatch(query)
+	if m == nil {
+		return nil, fmt.Errorf("fakeDB: query without a name: %s", query)
+	}
+	db.mu.Lock()
+	result, ok := db.results[m[1]]
+	db.calls[m[1]]++
+	db.mu.Unlock()
+	if !ok {
+		db.t.Errorf("fakeDB: unexpected query %s", m[1])
+		return nil, fmt.Errorf("fakeDB: unexpected query %s", m[1])
+	}
+	return result(args), nil
+}
+
+type fakeConnector struct{ db *fakeDB }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	rows, err := s.db.run(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(rows)), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows, err := s.db.run(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next == len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+// chirpRow is c in the column order of the chirps table.
+func chirpRow(c database.Chirp) []driver.Value {
+	nullable := func(v driver.Valuer) driver.Value {
+		value, _ := v.Value()
+		return value
+	}
+	return []driver.Value{
+		c.ID.String(), c.CreatedAt, c.UpdatedAt, c.UserID.String(), c.Body,
+		nullable(c.EditedAt), nullable(c.InReplyTo), int64(c.ReplyCount),
+		int64(c.LikeCount), nullable(c.RechirpOf), nullable(c.QuoteOf), c.IsQuote,
+	}
+}
+
+// userRow is u in the column order of the users table.
+func userRow(u database.User) []driver.Value {
+	nullable := func(v driver.Valuer) driver.Value {
+		value, _ := v.Value()
+		return value
+	}
+	return []driver.Value{
+		u.ID.String(), u.CreatedAt, u.UpdatedAt, u.Email, u.HashedPassword,
+		u.IsChirpyRed, u.Handle, u.DisplayName, u.Bio, u.Location,
+		nullable(u.EmailVerifiedAt), nullable(u.DeletedAt), int64(u.TokenVersion),
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type followResponse struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// loadPathUser resolves the {userID} path value to an existing user, writing
+// the error response itself when it cannot.
+func (cfg *apiConfig) loadPathUser(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		responseWithJsonError(w, "Invalid user ID", 400)
+		return database.User{}, false
+	}
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			responseWithJsonError(w, "User not found", 404)
+		} else {
+			responseWithJsonError(w, err.Error(), 500)
+		}
+		return database.User{}, false
+	}
+	return user, true
+}
+
+func (cfg *apiConfig) handleFollowUser(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	followee, ok := cfg.loadPathUser(w, r)
+	if !ok {
+		return
+	}
+	if followee.ID == user.ID {
+		responseWithJsonError(w, "You cannot follow yourself", 400)
+		return
+	}
+	err := cfg.db.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: user.ID,
+		FolloweeID: followee.ID,
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleUnfollowUser(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	followee, ok := cfg.loadPathUser(w, r)
+	if !ok {
+		return
+	}
+	err := cfg.db.DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: user.ID,
+		FolloweeID: followee.ID,
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleGetFollowers(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.loadPathUser(w, r)
+	if !ok {
+		return
+	}
+	page, err := parsePageRequest(r)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 400)
+		return
+	}
+	rows, err := cfg.db.GetFollowers(r.Context(), database.GetFollowersParams{
+		UserID:          user.ID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	follows := make([]followResponse, 0, len(rows))
+	for _, row := range rows {
+		follows = append(follows, followResponse{UserID: row.UserID, FollowedAt: row.FollowedAt})
+	}
+	follows, nextCursor := trimPage(follows, page.Limit, followCursor)
+	responseWithJson(pageResponse[followResponse]{Data: follows, NextCursor: nextCursor}, w, http.StatusOK)
+}
+
+func (cfg *apiConfig) handleGetFollowing(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.loadPathUser(w, r)
+	if !ok {
+		return
+	}
+	page, err := parsePageRequest(r)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 400)
+		return
+	}
+	rows, err := cfg.db.GetFollowing(r.Context(), database.GetFollowingParams{
+		UserID:          user.ID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	follows := make([]followResponse, 0, len(rows))
+	for _, row := range rows {
+		follows = append(follows, followResponse{UserID: row.UserID, FollowedAt: row.FollowedAt})
+	}
+	follows, nextCursor := trimPage(follows, page.Limit, followCursor)
+	responseWithJson(pageResponse[followResponse]{Data: follows, NextCursor: nextCursor}, w, http.StatusOK)
+}
+
+// handleGetTimeline lists chirps from everyone the user follows, with the
+// same sort and cursor parameters as GET /api/chirps.
+func (cfg *apiConfig) handleGetTimeline(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	sortOrder := parseSortOrder(r)
+	page, err := parsePageRequest(r)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 400)
+		return
+	}
+	params := database.GetTimelinePageAscParams{
+		UserID:          user.ID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	}
+	var chirps []database.Chirp
+	if sortOrder == "desc" {
+		chirps, err = cfg.db.GetTimelinePageDesc(r.Context(), database.GetTimelinePageDescParams(params))
+	} else {
+		chirps, err = cfg.db.GetTimelinePageAsc(r.Context(), params)
+	}
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	chirps, nextCursor := trimPage(chirps, page.Limit, chirpCursor)
+	chirpsResponse := mapChirpsToResponse(chirps)
+	if err := cfg.decorateChirps(r, chirpsResponse); err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	responseWithJson(pageResponse[chirpResponse]{
+		Data:       chirpsResponse,
+		NextCursor: nextCursor,
+	}, w, http.StatusOK)
+}
+
+func followCursor(follow followResponse) any {
+	return pageCursor{CreatedAt: follow.FollowedAt, ID: follow.UserID}
+}
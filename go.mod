@@ -2,12 +2,13 @@ module github.com/ZDSDD/Chirpy
 
 go 1.23.1
 
-require golang.org/x/crypto v0.27.0
-
 require (
-	github.com/golang-jwt/jwt/v5 v5.2.1 // indirect
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
-	github.com/lib/pq v1.10.9 // indirect
-	github.com/wagslane/go-password-validator v0.3.0 // indirect
+	github.com/golang-jwt/jwt/v5 v5.2.1
+	github.com/google/uuid v1.6.0
+	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.10.9
+	github.com/wagslane/go-password-validator v0.3.0
+	golang.org/x/crypto v0.27.0
 )
+
+require golang.org/x/sys v0.25.0 // indirect
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/ZDSDD/Chirpy/internal/entities"
+)
+
+const (
+	defaultTrendingWindow = 24 * time.Hour
+	maxTrendingWindow     = 7 * 24 * time.Hour
+)
+
+type trendingHashtagResponse struct {
+	Tag  string `json:"tag"`
+	Uses int64  `json:"uses"`
+}
+
+// syncChirpHashtags replaces the hashtags linked to a chirp with the ones
+// found in its current body. Pass queries bound to the transaction that
+// wrote the chirp.
+//
+// Links are dated by the chirp, not the sync, so editing an old chirp
+// doesn't push its tags back into trending.
+func syncChirpHashtags(ctx context.Context, q *database.Queries, chirp *database.Chirp) error {
+	if err := q.DeleteChirpHashtags(ctx, chirp.ID); err != nil {
+		return err
+	}
+	for _, tag := range entities.ExtractHashtags(chirp.Body) {
+		hashtag, err := q.UpsertHashtag(ctx, tag)
+		if err != nil {
+			return err
+		}
+		err = q.CreateChirpHashtag(ctx, database.CreateChirpHashtagParams{
+			ChirpID:   chirp.ID,
+			HashtagID: hashtag.ID,
+			CreatedAt: chirp.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) handleGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := entities.NormalizeHashtag(r.PathValue("tag"))
+	if tag == "" {
+		responseWithJsonError(w, "Tag is required", 400)
+		return
+	}
+	page, err := parsePageRequest(r)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 400)
+		return
+	}
+	chirps, err := cfg.db.GetChirpsByHashtag(r.Context(), database.GetChirpsByHashtagParams{
+		Tag:             tag,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	chirps, nextCursor := trimPage(chirps, page.Limit, chirpCursor)
+	chirpsResponse := mapChirpsToResponse(chirps)
+	if err := cfg.decorateChirps(r, chirpsResponse); err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	responseWithJson(pageResponse[chirpResponse]{
+		Data:       chirpsResponse,
+		NextCursor: nextCursor,
+	}, w, http.StatusOK)
+}
+
+// handleGetTrendingHashtags ranks tags by how many chirps used them within
+// the trailing window, e.g. ?window=24h.
+func (cfg *apiConfig) handleGetTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	window := defaultTrendingWindow
+	if raw := r.URL.Query().Get("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 || parsed > maxTrendingWindow {
+			responseWithJsonError(w, "window must be a duration between 0 and 168h, e.g. 24h", 400)
+			return
+		}
+		window = parsed
+	}
+	limit, err := parsePageLimit(r)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 400)
+		return
+	}
+	rows, err := cfg.db.GetTrendingHashtags(r.Context(), database.GetTrendingHashtagsParams{
+		Since:     time.Now().Add(-window),
+		PageLimit: limit,
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	trending := make([]trendingHashtagResponse, 0, len(rows))
+	for _, row := range rows {
+		trending = append(trending, trendingHashtagResponse{Tag: row.Tag, Uses: row.Uses})
+	}
+	responseWithJson(trending, w, http.StatusOK)
+}
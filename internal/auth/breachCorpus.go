@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// BreachCorpus is a local copy of a breached-password list such as Have I
+// Been Pwned's "ordered by hash" download: one upper-case SHA-1 per line,
+// optionally followed by ":count", sorted by hash. Lookups binary search the
+// file, so even the full multi-gigabyte list needs no memory up front.
+type BreachCorpus struct {
+	f    *os.File
+	size int64
+}
+
+func OpenBreachCorpus(path string) (*BreachCorpus, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &BreachCorpus{f: f, size: info.Size()}, nil
+}
+
+func (c *BreachCorpus) Close() error {
+	return c.f.Close()
+}
+
+// Range implements BreachedPasswords.
+func (c *BreachCorpus) Range(prefix string) ([]string, error) {
+	prefix = strings.ToUpper(prefix)
+	var searchErr error
+	// Find the first line whose hash sorts at or after prefix. Each offset
+	// stands for the first line starting at or after it, which keeps the
+	// predicate monotonic for sort.Search.
+	off := sort.Search(int(c.size), func(i int) bool {
+		if searchErr != nil {
+			return true
+		}
+		line, _, err := c.lineAt(int64(i))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return line == "" || hashKey(line) >= prefix
+	})
+	if searchErr != nil {
+		return nil, searchErr
+	}
+	_, start, err := c.lineAt(int64(off))
+	if err != nil {
+		return nil, err
+	}
+
+	var suffixes []string
+	scanner := bufio.NewScanner(io.NewSectionReader(c.f, start, c.size-start))
+	for scanner.Scan() {
+		hash := hashKey(scanner.Text())
+		if !strings.HasPrefix(hash, prefix) {
+			break
+		}
+		suffixes = append(suffixes, hash[len(prefix):])
+	}
+	return suffixes, scanner.Err()
+}
+
+// lineAt returns the first line starting at or after off, and where it
+// starts. At the end of the file the line is empty.
+func (c *BreachCorpus) lineAt(off int64) (string, int64, error) {
+	buf := make([]byte, 128)
+	start := off
+	if off > 0 {
+		// Skip the rest of the line off falls in, unless off is where a
+		// line starts.
+		start = off - 1
+		for {
+			n, err := c.f.ReadAt(buf, start)
+			if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+				start += int64(i) + 1
+				break
+			}
+			start += int64(n)
+			if err == io.EOF {
+				return "", c.size, nil
+			}
+			if err != nil {
+				return "", 0, err
+			}
+		}
+	}
+	n, err := c.f.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		return "", 0, err
+	}
+	line, _, _ := bytes.Cut(buf[:n], []byte("\n"))
+	if n == len(buf) && len(line) == n {
+		return "", 0, fmt.Errorf("breach corpus line at offset %d is too long", start)
+	}
+	return string(line), start, nil
+}
+
+func hashKey(line string) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	return strings.ToUpper(hash)
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func writeBreachCorpus(t *testing.T, passwords ...string) string {
+	t.Helper()
+	var hashes []string
+	for _, p := range passwords {
+		hashes = append(hashes, sha1Hex(p))
+	}
+	slices.Sort(hashes)
+	var b strings.Builder
+	for i, h := range hashes {
+		fmt.Fprintf(&b, "%s:%d\r\n", h, i+1)
+	}
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBreachCorpus(t *testing.T) {
+	var passwords []string
+	for i := range 500 {
+		passwords = append(passwords, fmt.Sprintf("password%d", i))
+	}
+	c, err := OpenBreachCorpus(writeBreachCorpus(t, passwords...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	for _, p := range append(passwords[:20], "password499") {
+		hash := sha1Hex(p)
+		suffixes, err := c.Range(hash[:5])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains(suffixes, hash[5:]) {
+			t.Errorf("Expected %q to be found", p)
+		}
+		for _, s := range suffixes {
+			if len(s) != 35 {
+				t.Errorf("Unexpected suffix %q", s)
+			}
+		}
+	}
+	for _, prefix := range []string{"00000", "FFFFF", strings.ToLower(sha1Hex("not breached")[:5])} {
+		suffixes, err := c.Range(prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slices.Contains(suffixes, sha1Hex("not breached")[5:]) {
+			t.Errorf("Unexpected match for prefix %s", prefix)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DenylistStore persists revoked token IDs so every instance sees them and
+// they survive a restart.
+type DenylistStore interface {
+	Deny(ctx context.Context, jti string, expiresAt time.Time) error
+	// Lookup reports whether jti is revoked and, if so, until when.
+	Lookup(ctx context.Context, jti string) (expiresAt time.Time, denied bool, err error)
+}
+
+// Denylist tracks revoked access tokens by jti until they would have expired
+// anyway. Revocations are cached in memory, and tokens not in the cache are
+// looked up in the store, which may have been told by another instance.
+type Denylist struct {
+	store DenylistStore
+	now   func() time.Time
+
+	mu     sync.Mutex
+	denied map[string]time.Time
+}
+
+func NewDenylist(store DenylistStore) *Denylist {
+	return &Denylist{store: store, now: time.Now, denied: map[string]time.Time{}}
+}
+
+// Deny revokes jti. expiresAt is the token's own expiry, after which there is
+// no need to remember it.
+func (d *Denylist) Deny(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := d.store.Deny(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+	d.remember(jti, expiresAt)
+	return nil
+}
+
+func (d *Denylist) IsDenied(ctx context.Context, jti string) (bool, error) {
+	d.mu.Lock()
+	_, ok := d.denied[jti]
+	d.mu.Unlock()
+	if ok {
+		return true, nil
+	}
+	expiresAt, denied, err := d.store.Lookup(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+	if denied {
+		d.remember(jti, expiresAt)
+	}
+	return denied, nil
+}
+
+func (d *Denylist) remember(jti string, expiresAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	for id, exp := range d.denied {
+		if now.After(exp) {
+			delete(d.denied, id)
+		}
+	}
+	if now.Before(expiresAt) {
+		d.denied[jti] = expiresAt
+	}
+}
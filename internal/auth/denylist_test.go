@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type memoryDenylistStore struct {
+	denied  map[string]time.Time
+	lookups int
+}
+
+func (s *memoryDenylistStore) Deny(_ context.Context, jti string, expiresAt time.Time) error {
+	s.denied[jti] = expiresAt
+	return nil
+}
+
+func (s *memoryDenylistStore) Lookup(_ context.Context, jti string) (time.Time, bool, error) {
+	s.lookups++
+	expiresAt, ok := s.denied[jti]
+	return expiresAt, ok, nil
+}
+
+func TestDenylist(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryDenylistStore{denied: map[string]time.Time{}}
+	d := NewDenylist(store)
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	if denied, _ := d.IsDenied(ctx, "a"); denied {
+		t.Error("Expected unknown jti not to be denied")
+	}
+	if err := d.Deny(ctx, "a", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	lookups := store.lookups
+	if denied, _ := d.IsDenied(ctx, "a"); !denied {
+		t.Error("Expected denied jti to be denied")
+	}
+	if store.lookups != lookups {
+		t.Error("Expected denied jti to be served from the cache")
+	}
+
+	// Revoked by another instance, so only the store knows about it.
+	store.denied["b"] = now.Add(time.Hour)
+	if denied, _ := d.IsDenied(ctx, "b"); !denied {
+		t.Error("Expected jti denied in the store to be denied")
+	}
+
+	now = now.Add(2 * time.Hour)
+	d.Deny(ctx, "c", now.Add(time.Hour))
+	d.mu.Lock()
+	_, cached := d.denied["a"]
+	d.mu.Unlock()
+	if cached {
+		t.Error("Expected expired jti to be dropped from the cache")
+	}
+}
@@ -0,0 +1,14 @@
+package auth
+
+import "testing"
+
+func TestHashToken(t *testing.T) {
+	// echo -n "token" | sha256sum
+	want := "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0"
+	if got := HashToken("token"); got != want {
+		t.Errorf("Expected hash to be '%s', got '%s'", want, got)
+	}
+	if HashToken("token") == HashToken("other") {
+		t.Error("Different tokens should not hash to the same value")
+	}
+}
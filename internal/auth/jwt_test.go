@@ -1,8 +1,11 @@
 package auth
 
 import (
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
@@ -29,3 +32,136 @@ func TestJwtValidate(t *testing.T) {
 		t.Errorf("Expected userId to be %s, got %s", uuid.Max, userId)
 	}
 }
+
+func TestValidateJWTClaims(t *testing.T) {
+	ks := NewHMACKeySet("secret")
+	ks.SetPolicy(TokenPolicy{Issuer: "chirpy", Audience: "chirpy", Leeway: 10 * time.Second})
+	now := time.Now()
+	valid := func() jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    "chirpy",
+			Subject:   uuid.Max.String(),
+			Audience:  jwt.ClaimStrings{"chirpy"},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		}
+	}
+	tests := []struct {
+		name    string
+		modify  func(c *jwt.RegisteredClaims)
+		wantErr error
+	}{
+		{
+			name:   "valid",
+			modify: func(c *jwt.RegisteredClaims) {},
+		},
+		{
+			name:    "expired",
+			modify:  func(c *jwt.RegisteredClaims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute)) },
+			wantErr: jwt.ErrTokenExpired,
+		},
+		{
+			name:   "expired within leeway",
+			modify: func(c *jwt.RegisteredClaims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-5 * time.Second)) },
+		},
+		{
+			name:    "missing expiry",
+			modify:  func(c *jwt.RegisteredClaims) { c.ExpiresAt = nil },
+			wantErr: jwt.ErrTokenRequiredClaimMissing,
+		},
+		{
+			name:    "not valid yet",
+			modify:  func(c *jwt.RegisteredClaims) { c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute)) },
+			wantErr: jwt.ErrTokenNotValidYet,
+		},
+		{
+			name:   "not valid yet within leeway",
+			modify: func(c *jwt.RegisteredClaims) { c.NotBefore = jwt.NewNumericDate(now.Add(5 * time.Second)) },
+		},
+		{
+			name:    "issued in the future",
+			modify:  func(c *jwt.RegisteredClaims) { c.IssuedAt = jwt.NewNumericDate(now.Add(time.Minute)) },
+			wantErr: jwt.ErrTokenUsedBeforeIssued,
+		},
+		{
+			name:    "wrong audience",
+			modify:  func(c *jwt.RegisteredClaims) { c.Audience = jwt.ClaimStrings{"other-service"} },
+			wantErr: jwt.ErrTokenInvalidAudience,
+		},
+		{
+			name:    "missing audience",
+			modify:  func(c *jwt.RegisteredClaims) { c.Audience = nil },
+			wantErr: jwt.ErrTokenRequiredClaimMissing,
+		},
+		{
+			name:    "missing jti",
+			modify:  func(c *jwt.RegisteredClaims) { c.ID = "" },
+			wantErr: errMissingJTI,
+		},
+		{
+			name:    "wrong issuer",
+			modify:  func(c *jwt.RegisteredClaims) { c.Issuer = "someone-else" },
+			wantErr: jwt.ErrTokenInvalidIssuer,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.modify(&claims)
+			token, err := ks.Sign(claims)
+			if err != nil {
+				t.Fatalf("Error signing JWT: %s", err)
+			}
+			userId, err := ks.ValidateJWT(token)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("Expected token to validate, got %s", err)
+				} else if userId != uuid.Max {
+					t.Errorf("Expected userId to be %s, got %s", uuid.Max, userId)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateJWTSigningMethods(t *testing.T) {
+	claims := jwt.RegisteredClaims{
+		Issuer:    "chirpy",
+		Subject:   uuid.Max.String(),
+		Audience:  jwt.ClaimStrings{"chirpy"},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs512, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSecret, err := MakeJWT(uuid.Max, "other", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"alg none", unsigned},
+		{"HS512", hs512},
+		{"wrong secret", otherSecret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ValidateJWT(tt.token, "secret"); err == nil {
+				t.Error("Expected token to be rejected")
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const minRSAKeyBits = 2048
+
+var errMissingJTI = errors.New("token has no jti")
+
+// Key is an Ed25519 or RSA key used to sign or verify JWTs. Keys loaded from
+// a public key can only verify.
+type Key struct {
+	ID      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// LoadKeyFile reads a PEM encoded key from path. See ParseKeyPEM.
+func LoadKeyFile(path string) (*Key, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseKeyPEM(dat)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// ParseKeyPEM accepts a PKCS #8 or PKCS #1 private key, or a PKIX public key.
+// Ed25519 keys sign with EdDSA and RSA keys with RS256. The key ID is the
+// RFC 7638 thumbprint of the public key.
+func ParseKeyPEM(dat []byte) (*Key, error) {
+	block, _ := pem.Decode(dat)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{}
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, k
+	case *rsa.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.method, key.public = jwt.SigningMethodRS256, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	if pub, ok := key.public.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA keys must be at least %d bits", minRSAKeyBits)
+	}
+	key.ID = key.JWK().thumbprint()
+	return key, nil
+}
+
+// JWK is the public half of a Key in RFC 7517 form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (k *Key) JWK() JWK {
+	jwk := JWK{Kid: k.ID, Alg: k.method.Alg(), Use: "sig"}
+	switch pub := k.public.(type) {
+	case ed25519.PublicKey:
+		jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	}
+	return jwk
+}
+
+// thumbprint hashes the required members of the JWK in lexicographic order,
+// as RFC 7638 specifies.
+func (jwk JWK) thumbprint() string {
+	var members any
+	if jwk.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+	dat, _ := json.Marshal(members)
+	sum := sha256.Sum256(dat)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// KeySet signs tokens with one key and verifies them with any key it holds,
+// so tokens signed before a key rotation stay valid until they expire.
+type KeySet struct {
+	signing    *Key
+	keys       map[string]*Key
+	hmacSecret []byte
+	policy     TokenPolicy
+}
+
+// TokenPolicy holds the registered claims a KeySet puts in the tokens it
+// issues and requires of the tokens it validates.
+type TokenPolicy struct {
+	Issuer   string
+	Audience string
+	// Leeway allows for clock skew when checking exp, nbf and iat.
+	Leeway time.Duration
+}
+
+var DefaultTokenPolicy = TokenPolicy{
+	Issuer:   "chirpy",
+	Audience: "chirpy",
+	Leeway:   30 * time.Second,
+}
+
+// NewKeySet signs with signing and also accepts tokens signed by any of the
+// verification keys.
+func NewKeySet(signing *Key, verification ...*Key) (*KeySet, error) {
+	if signing == nil || signing.private == nil {
+		return nil, errors.New("signing key must be a private key")
+	}
+	ks := &KeySet{
+		signing: signing,
+		keys:    map[string]*Key{signing.ID: signing},
+		policy:  DefaultTokenPolicy,
+	}
+	for _, key := range verification {
+		ks.keys[key.ID] = key
+	}
+	return ks, nil
+}
+
+// NewHMACKeySet signs and verifies with a shared HS256 secret. It has no
+// public keys to publish.
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{hmacSecret: []byte(secret), policy: DefaultTokenPolicy}
+}
+
+func (ks *KeySet) SetPolicy(policy TokenPolicy) {
+	ks.policy = policy
+}
+
+// AcceptHMAC makes ks also accept HS256 tokens signed with secret, for tokens
+// issued before switching to asymmetric keys.
+func (ks *KeySet) AcceptHMAC(secret string) {
+	ks.hmacSecret = []byte(secret)
+}
+
+// JWKS returns the public verification keys, signing key first.
+func (ks *KeySet) JWKS() JWKSet {
+	if ks.signing == nil {
+		return JWKSet{Keys: []JWK{}}
+	}
+	var others []JWK
+	for id, key := range ks.keys {
+		if id != ks.signing.ID {
+			others = append(others, key.JWK())
+		}
+	}
+	slices.SortFunc(others, func(a, b JWK) int { return strings.Compare(a.Kid, b.Kid) })
+	return JWKSet{Keys: append([]JWK{ks.signing.JWK()}, others...)}
+}
+
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.hmacSecret)
+	}
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.private)
+}
+
+// Parse verifies tokenString and decodes its claims into claims. Besides the
+// signature it checks exp (which must be present), nbf and iat, and requires
+// the issuer and audience of the policy.
+func (ks *KeySet) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, ks.keyfunc,
+		jwt.WithValidMethods(ks.validMethods()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(ks.policy.Leeway),
+		jwt.WithIssuer(ks.policy.Issuer),
+		jwt.WithAudience(ks.policy.Audience),
+	)
+}
+
+func (ks *KeySet) keyfunc(token *jwt.Token) (any, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		if ks.hmacSecret == nil || token.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("token has no key ID")
+		}
+		return ks.hmacSecret, nil
+	}
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	// Never let the token pick an algorithm other than the key's own.
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("key %q does not sign with %s", kid, token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+func (ks *KeySet) validMethods() []string {
+	var methods []string
+	if ks.hmacSecret != nil {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	seen := map[string]bool{}
+	for _, key := range ks.keys {
+		if alg := key.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// AccessClaims are the claims of a Chirpy access token. ID (jti) names the
+// token so it can be revoked on its own, and TokenVersion must match the
+// user's current version, so bumping that revokes every token at once.
+type AccessClaims struct {
+	jwt.RegisteredClaims
+	TokenVersion int32 `json:"ver"`
+}
+
+func (ks *KeySet) MakeAccessToken(userID uuid.UUID, tokenVersion int32, expiresIn time.Duration) (string, error) {
+	now := time.Now()
+	return ks.Sign(AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    ks.policy.Issuer,
+			Subject:   userID.String(),
+			Audience:  jwt.ClaimStrings{ks.policy.Audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		TokenVersion: tokenVersion,
+	})
+}
+
+func (ks *KeySet) ParseAccessToken(tokenString string) (*AccessClaims, error) {
+	var claims AccessClaims
+	if _, err := ks.Parse(tokenString, &claims); err != nil {
+		return nil, err
+	}
+	if claims.ID == "" {
+		return nil, errMissingJTI
+	}
+	if _, err := uuid.Parse(claims.Subject); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// UserID is the subject, which ParseAccessToken has already checked.
+func (c *AccessClaims) UserID() uuid.UUID {
+	return uuid.MustParse(c.Subject)
+}
+
+func (ks *KeySet) MakeJWT(userID uuid.UUID, expiresIn time.Duration) (string, error) {
+	return ks.MakeAccessToken(userID, 0, expiresIn)
+}
+
+func (ks *KeySet) ValidateJWT(tokenString string) (uuid.UUID, error) {
+	claims, err := ks.ParseAccessToken(tokenString)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	return claims.UserID(), nil
+}
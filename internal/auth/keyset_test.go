@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func newEd25519Key(t *testing.T) *Key {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsePrivateKey(t, priv)
+}
+
+func newRSAKey(t *testing.T) *Key {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsePrivateKey(t, priv)
+}
+
+func parsePrivateKey(t *testing.T, priv any) *Key {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("Error parsing key: %s", err)
+	}
+	return key
+}
+
+func publicOnly(t *testing.T, key *Key) *Key {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key.public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParseKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("Error parsing public key: %s", err)
+	}
+	return pub
+}
+
+func TestKeySetSignAndValidate(t *testing.T) {
+	for name, key := range map[string]*Key{
+		"EdDSA": newEd25519Key(t),
+		"RS256": newRSAKey(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ks, err := NewKeySet(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := ks.MakeJWT(uuid.Max, time.Hour)
+			if err != nil {
+				t.Fatalf("Error generating JWT: %s", err)
+			}
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &jwt.RegisteredClaims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.Header["kid"] != key.ID || parsed.Method.Alg() != name {
+				t.Errorf("Expected kid %s and alg %s, got %v", key.ID, name, parsed.Header)
+			}
+			userId, err := ks.ValidateJWT(token)
+			if err != nil {
+				t.Errorf("Error validating JWT: %s", err)
+			}
+			if userId != uuid.Max {
+				t.Errorf("Expected userId to be %s, got %s", uuid.Max, userId)
+			}
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	oldKey, newKey := newEd25519Key(t), newRSAKey(t)
+	oldKeys, _ := NewKeySet(oldKey)
+	oldToken, err := oldKeys.MakeJWT(uuid.Max, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := NewKeySet(newKey, publicOnly(t, oldKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rotated.ValidateJWT(oldToken); err != nil {
+		t.Errorf("Expected token from the previous key to validate, got %s", err)
+	}
+	jwks := rotated.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kid != newKey.ID || jwks.Keys[1].Kid != oldKey.ID {
+		t.Errorf("Expected JWKS to list the new then the old key, got %+v", jwks.Keys)
+	}
+
+	dropped, _ := NewKeySet(newKey)
+	if _, err := dropped.ValidateJWT(oldToken); err == nil {
+		t.Error("Expected token from a retired key to be rejected")
+	}
+}
+
+func TestKeySetRejectsAlgorithmSwitch(t *testing.T) {
+	key := newRSAKey(t)
+	ks, _ := NewKeySet(key)
+	// Sign HS256 with the public key bytes, pretending to come from the RSA key.
+	pubDER, _ := x509.MarshalPKIXPublicKey(key.public)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: uuid.Max.String()})
+	token.Header["kid"] = key.ID
+	forged, err := token.SignedString(pubDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.ValidateJWT(forged); err == nil {
+		t.Error("Expected HS256 token to be rejected by an RSA key set")
+	}
+}
+
+func TestKeySetHMACFallback(t *testing.T) {
+	legacy, err := MakeJWT(uuid.Max, "secret", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, _ := NewKeySet(newEd25519Key(t))
+	if _, err := ks.ValidateJWT(legacy); err == nil {
+		t.Error("Expected HS256 token to be rejected without AcceptHMAC")
+	}
+	ks.AcceptHMAC("secret")
+	if _, err := ks.ValidateJWT(legacy); err != nil {
+		t.Errorf("Expected HS256 token to validate with AcceptHMAC, got %s", err)
+	}
+}
+
+func TestParseKeyPEMRejectsGarbage(t *testing.T) {
+	if _, err := ParseKeyPEM([]byte("not a key")); err == nil {
+		t.Error("Expected error parsing a non-PEM key")
+	}
+	small, _ := rsa.GenerateKey(rand.Reader, 1024)
+	der, _ := x509.MarshalPKCS8PrivateKey(small)
+	if _, err := ParseKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})); err == nil {
+		t.Error("Expected error parsing a 1024-bit RSA key")
+	}
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	// RFC 7638 section 3.1.
+	jwk := JWK{
+		Kty: "RSA",
+		E:   "AQAB",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECP" +
+			"ebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY" +
+			"368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0f" +
+			"M4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	}
+	if got, want := jwk.thumbprint(), "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; got != want {
+		t.Errorf("Expected thumbprint %s, got %s", want, got)
+	}
+}
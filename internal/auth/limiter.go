@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// LimiterPolicy configures a Limiter. The first FreeFailures failures within
+// Window cost nothing; each one after that blocks the key for twice as long
+// as the one before, starting at BaseDelay, until LockoutAfter failures lock
+// it out for Lockout.
+type LimiterPolicy struct {
+	FreeFailures int
+	BaseDelay    time.Duration
+	LockoutAfter int
+	Lockout      time.Duration
+	// Window is how long after its last failure a key is forgotten.
+	Window time.Duration
+}
+
+// Limiter slows down repeated failures per key, e.g. per account or per IP,
+// to make guessing passwords impractical. State is kept in memory, so each
+// instance counts on its own and a restart forgets it.
+type Limiter struct {
+	policy LimiterPolicy
+	now    func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*limiterEntry
+	lastSweep time.Time
+}
+
+type limiterEntry struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+func NewLimiter(policy LimiterPolicy) *Limiter {
+	return &Limiter{policy: policy, now: time.Now, entries: map[string]*limiterEntry{}}
+}
+
+// Allow reports whether key may try now and, if not, how long it has to
+// wait. An allowed attempt is counted as a failure straight away, so
+// concurrent attempts can't all slip through before any of them fails; call
+// Reset or Release once it turns out to have succeeded.
+func (l *Limiter) Allow(key string) (retryAfter time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+	e, found := l.entries[key]
+	if found {
+		if wait := e.blockedUntil.Sub(now); wait > 0 {
+			return wait, false
+		}
+	}
+	if !found || now.Sub(e.lastFailure) > l.policy.Window {
+		e = &limiterEntry{}
+		l.entries[key] = e
+	}
+	e.failures++
+	e.lastFailure = now
+	e.blockedUntil = l.blockedUntil(e)
+	return 0, true
+}
+
+// Release takes back the attempt counted by the last Allow for key, keeping
+// earlier failures. It suits keys shared by many users, like an IP address,
+// where one success says nothing about the others.
+func (l *Limiter) Release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, found := l.entries[key]
+	if !found || e.failures == 0 {
+		return
+	}
+	e.failures--
+	e.blockedUntil = l.blockedUntil(e)
+}
+
+// blockedUntil is when e may try again after its last failure.
+func (l *Limiter) blockedUntil(e *limiterEntry) time.Time {
+	switch {
+	case e.failures >= l.policy.LockoutAfter:
+		return e.lastFailure.Add(l.policy.Lockout)
+	case e.failures > l.policy.FreeFailures:
+		delay := l.policy.BaseDelay << (e.failures - l.policy.FreeFailures - 1)
+		return e.lastFailure.Add(min(delay, l.policy.Lockout))
+	}
+	return time.Time{}
+}
+
+// Reset forgets key's failures, e.g. after it logged in successfully.
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, key)
+}
+
+// sweep drops forgotten keys, at most once per Window so Allow stays cheap.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.policy.Window {
+		return
+	}
+	l.lastSweep = now
+	for key, e := range l.entries {
+		if now.Sub(e.lastFailure) > l.policy.Window && now.After(e.blockedUntil) {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(LimiterPolicy{
+		FreeFailures: 2,
+		BaseDelay:    time.Second,
+		LockoutAfter: 5,
+		Lockout:      time.Minute,
+		Window:       time.Hour,
+	})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	// The wait after each failed attempt, which has to pass before the next.
+	wantWaits := []time.Duration{0, 0, time.Second, 2 * time.Second, time.Minute}
+	for i, want := range wantWaits {
+		if _, ok := l.Allow("alice"); !ok {
+			t.Fatalf("Expected attempt %d to be allowed", i+1)
+		}
+		if want == 0 {
+			continue
+		}
+		wait, ok := l.Allow("alice")
+		if ok || wait != want {
+			t.Errorf("After %d failures: expected wait %s, got %s (ok %v)", i+1, want, wait, ok)
+		}
+		now = now.Add(want)
+	}
+	if _, ok := l.Allow("bob"); !ok {
+		t.Error("Failures of one key should not block another")
+	}
+
+	l.Reset("alice")
+	l.Allow("alice")
+	if _, ok := l.Allow("alice"); !ok {
+		t.Error("Expected Reset to forget earlier failures")
+	}
+}
+
+func TestLimiterRelease(t *testing.T) {
+	l := NewLimiter(LimiterPolicy{
+		FreeFailures: 1,
+		BaseDelay:    time.Second,
+		LockoutAfter: 10,
+		Lockout:      time.Minute,
+		Window:       time.Hour,
+	})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	l.Allow("10.0.0.1")
+	l.Allow("10.0.0.1")
+	if _, ok := l.Allow("10.0.0.1"); ok {
+		t.Fatal("Expected the second failure to block")
+	}
+	l.Release("10.0.0.1")
+	if _, ok := l.Allow("10.0.0.1"); !ok {
+		t.Error("Expected Release to take back the blocking attempt")
+	}
+	if got := l.entries["10.0.0.1"].failures; got != 2 {
+		t.Errorf("Expected Release to keep earlier failures, got %d", got)
+	}
+}
+
+func TestLimiterConcurrentAttempts(t *testing.T) {
+	l := NewLimiter(LimiterPolicy{
+		FreeFailures: 2,
+		BaseDelay:    time.Second,
+		LockoutAfter: 10,
+		Lockout:      time.Minute,
+		Window:       time.Hour,
+	})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := l.Allow("alice"); ok {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 3 {
+		t.Errorf("Expected 3 attempts to get through at once, got %d", allowed)
+	}
+}
+
+func TestLimiterWindow(t *testing.T) {
+	l := NewLimiter(LimiterPolicy{
+		FreeFailures: 1,
+		BaseDelay:    time.Second,
+		LockoutAfter: 10,
+		Lockout:      time.Minute,
+		Window:       time.Hour,
+	})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	l.Allow("alice")
+	now = now.Add(2 * time.Hour)
+	l.Allow("alice")
+	if _, ok := l.Allow("alice"); !ok {
+		t.Error("Expected failures outside the window to be forgotten")
+	}
+	if len(l.entries) != 1 {
+		t.Errorf("Expected 1 tracked key, got %d", len(l.entries))
+	}
+}
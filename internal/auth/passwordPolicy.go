@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	passwordvalidator "github.com/wagslane/go-password-validator"
+)
+
+// PasswordPolicyError explains why a password was rejected. Any other error
+// from PasswordPolicy.Check means the check itself failed.
+type PasswordPolicyError struct {
+	Reason string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return e.Reason
+}
+
+// BreachedPasswords looks up known-breached passwords by k-anonymity: given
+// the first five hex digits of a password's SHA-1, it returns the remaining
+// 35 digits of every breached password with that prefix, in upper case.
+type BreachedPasswords interface {
+	Range(prefix string) ([]string, error)
+}
+
+// PasswordPolicy decides which passwords users may choose.
+type PasswordPolicy struct {
+	// MinLength counts characters, not bytes.
+	MinLength int
+	// MinEntropy is in bits, as estimated by go-password-validator.
+	MinEntropy float64
+	// Breached, when set, rejects passwords known from data breaches.
+	Breached BreachedPasswords
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:  8,
+	MinEntropy: 50,
+}
+
+// Check returns a *PasswordPolicyError if password breaks the policy.
+// personal lists things like the user's email and handle, which the
+// password must not be built from.
+func (p PasswordPolicy) Check(password string, personal ...string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return &PasswordPolicyError{fmt.Sprintf("Password must be at least %d characters long", p.MinLength)}
+	}
+	if reason := similarTo(password, personal); reason != "" {
+		return &PasswordPolicyError{"Password must not contain your " + reason}
+	}
+	if err := passwordvalidator.Validate(password, p.MinEntropy); err != nil {
+		return &PasswordPolicyError{err.Error()}
+	}
+	if p.Breached != nil {
+		breached, err := isBreached(p.Breached, password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			return &PasswordPolicyError{"Password has appeared in a data breach, choose a different one"}
+		}
+	}
+	return nil
+}
+
+// similarTo returns what password is too similar to, if anything. Emails are
+// compared by their local part too, since that is usually what gets reused.
+func similarTo(password string, personal []string) string {
+	lower := strings.ToLower(password)
+	for _, s := range personal {
+		s = strings.ToLower(s)
+		candidates := []string{s}
+		if local, _, ok := strings.Cut(s, "@"); ok {
+			candidates = append(candidates, local)
+		}
+		for _, c := range candidates {
+			// Very short names would match by accident.
+			if len(c) < 3 {
+				continue
+			}
+			if strings.Contains(lower, c) || strings.Contains(c, lower) {
+				if strings.Contains(s, "@") {
+					return "email address"
+				}
+				return "name or handle"
+			}
+		}
+	}
+	return ""
+}
+
+func isBreached(source BreachedPasswords, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes, err := source.Range(digest[:5])
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(suffixes, digest[5:]), nil
+}
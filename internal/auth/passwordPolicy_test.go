@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	c, err := OpenBreachCorpus(writeBreachCorpus(t, "correct horse battery staple", "hunter2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	policy := DefaultPasswordPolicy
+	policy.Breached = c
+
+	tests := []struct {
+		name     string
+		password string
+		ok       bool
+	}{
+		{"strong", "vX9#qLm2!rTz7$wB", true},
+		{"too short", "aB3$", false},
+		{"low entropy", "aaaaaaaaaaaa", false},
+		{"contains handle", "Xx_alice_rocks_42!", false},
+		{"contains email local part", "ALICE.SMITH#2024!zz", false},
+		{"breached", "correct horse battery staple", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.password, "alice.smith@example.com", "alice")
+			if tt.ok && err != nil {
+				t.Fatalf("Expected password to be accepted, got %s", err)
+			}
+			var policyErr *PasswordPolicyError
+			if !tt.ok && !errors.As(err, &policyErr) {
+				t.Fatalf("Expected a PasswordPolicyError, got %v", err)
+			}
+		})
+	}
+}
+
+type failingBreachedPasswords struct{}
+
+func (failingBreachedPasswords) Range(string) ([]string, error) {
+	return nil, errors.New("corpus unavailable")
+}
+
+func TestPasswordPolicyBreachedError(t *testing.T) {
+	policy := DefaultPasswordPolicy
+	policy.Breached = failingBreachedPasswords{}
+	err := policy.Check("vX9#qLm2!rTz7$wB")
+	var policyErr *PasswordPolicyError
+	if err == nil || errors.As(err, &policyErr) {
+		t.Errorf("Expected the lookup error, got %v", err)
+	}
+}
@@ -1,12 +1,189 @@
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrPasswordMismatch  = errors.New("password does not match")
+	ErrPasswordTooLong   = errors.New("password is longer than 72 bytes")
+	ErrUnknownHashFormat = errors.New("unknown password hash format")
+	errVerifyOnly        = errors.New("hasher is verify-only")
+)
+
+// PasswordHasher handles one password hashing scheme. Hashes are PHC
+// strings ("$id$params$salt$hash"), so the scheme and its parameters are
+// stored alongside each hash.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) error
+	// NeedsRehash reports whether encoded uses weaker parameters than Hash
+	// would use now.
+	NeedsRehash(encoded string) bool
+}
+
+// passwordHashers maps PHC identifiers to the hasher that reads them. New
+// hashes always use defaultPasswordHasher; the others are only verified,
+// until a login rehashes them.
+var (
+	passwordHashers = map[string]PasswordHasher{
+		"argon2id": DefaultArgon2id,
+		"2a":       bcryptHasher{},
+		"2b":       bcryptHasher{},
+		"2y":       bcryptHasher{},
+	}
+	defaultPasswordHasher = "argon2id"
+)
 
 func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(hash), err
+	return passwordHashers[defaultPasswordHasher].Hash(password)
 }
 
 func CheckPasswordHash(password, hash string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	h, ok := passwordHashers[phcID(hash)]
+	if !ok {
+		return ErrUnknownHashFormat
+	}
+	return h.Verify(password, hash)
+}
+
+// PasswordNeedsRehash reports whether hash should be replaced by a fresh
+// HashPassword of the same password, the next time it is known.
+func PasswordNeedsRehash(hash string) bool {
+	id := phcID(hash)
+	if id != defaultPasswordHasher {
+		return true
+	}
+	return passwordHashers[id].NeedsRehash(hash)
+}
+
+func phcID(hash string) string {
+	if !strings.HasPrefix(hash, "$") {
+		return ""
+	}
+	id, _, _ := strings.Cut(hash[1:], "$")
+	return id
+}
+
+// Argon2idHasher hashes with Argon2id (RFC 9106). Memory is in KiB.
+type Argon2idHasher struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	SaltLen int
+	KeyLen  uint32
+}
+
+// DefaultArgon2id follows the OWASP password storage recommendation.
+var DefaultArgon2id = Argon2idHasher{
+	Time:    2,
+	Memory:  19 * 1024,
+	Threads: 1,
+	SaltLen: 16,
+	KeyLen:  32,
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h Argon2idHasher) Verify(password, encoded string) error {
+	params, salt, key, err := parseArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+	got := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(got, key) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func (h Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, salt, key, err := parseArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Time < h.Time || params.Memory < h.Memory || params.Threads < h.Threads ||
+		len(salt) < h.SaltLen || uint32(len(key)) < h.KeyLen
+}
+
+func parseArgon2id(encoded string) (params Argon2idHasher, salt, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads)
+	if err != nil || params.Time == 0 || params.Threads == 0 {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+	return params, salt, key, nil
+}
+
+// bcryptHasher verifies the bcrypt hashes stored before Argon2id. It doesn't
+// make new ones: bcrypt only looks at the first 72 bytes of a password.
+type bcryptHasher struct{}
+
+func (bcryptHasher) Hash(string) (string, error) {
+	return "", errVerifyOnly
+}
+
+func (bcryptHasher) Verify(password, encoded string) error {
+	// Anything past 72 bytes would be ignored, so a longer password can't be
+	// the one that was hashed.
+	if len(password) > 72 {
+		return ErrPasswordTooLong
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+func (bcryptHasher) NeedsRehash(string) bool {
+	return true
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// CheckPasswordDummy spends as long as CheckPasswordHash would, for logins
+// with an unknown email, so response times don't reveal which emails have
+// accounts.
+func CheckPasswordDummy(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = HashPassword("chirpy-dummy-password")
+	})
+	CheckPasswordHash(password, dummyHash)
 }
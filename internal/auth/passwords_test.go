@@ -1,6 +1,12 @@
 package auth
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 func TestHashPassword(t *testing.T) {
 	password := "password"
@@ -34,3 +40,62 @@ func TestCheckPasswordHash(t *testing.T) {
 		t.Error("CheckPasswordHash should return an error")
 	}
 }
+
+func TestHashPasswordArgon2id(t *testing.T) {
+	hash, err := HashPassword("password")
+	if err != nil {
+		t.Fatalf("Error hashing password: %s", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Errorf("Unexpected hash format %q", hash)
+	}
+	if PasswordNeedsRehash(hash) {
+		t.Error("A fresh hash should not need rehashing")
+	}
+	long := strings.Repeat("a", 100)
+	hash, err = HashPassword(long)
+	if err != nil {
+		t.Fatalf("Error hashing long password: %s", err)
+	}
+	if err := CheckPasswordHash(long[:72], hash); err == nil {
+		t.Error("Expected passwords to be compared beyond 72 bytes")
+	}
+}
+
+func TestCheckPasswordHashBcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := string(legacy)
+	if err := CheckPasswordHash("password", hash); err != nil {
+		t.Errorf("Error checking bcrypt hash: %s", err)
+	}
+	if err := CheckPasswordHash("wrongpassword", hash); !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("Expected ErrPasswordMismatch, got %v", err)
+	}
+	if err := CheckPasswordHash("password"+strings.Repeat("x", 72), hash); !errors.Is(err, ErrPasswordTooLong) {
+		t.Errorf("Expected ErrPasswordTooLong, got %v", err)
+	}
+	if !PasswordNeedsRehash(hash) {
+		t.Error("Expected bcrypt hashes to need rehashing")
+	}
+	if _, err := (bcryptHasher{}).Hash("password"); err == nil {
+		t.Error("Expected bcrypt to be verify-only")
+	}
+}
+
+func TestPasswordNeedsRehashWeakArgon2id(t *testing.T) {
+	weak := DefaultArgon2id
+	weak.Time = 1
+	hash, err := weak.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckPasswordHash("password", hash); err != nil {
+		t.Errorf("Error checking hash with old parameters: %s", err)
+	}
+	if !PasswordNeedsRehash(hash) {
+		t.Error("Expected hash with weaker parameters to need rehashing")
+	}
+}
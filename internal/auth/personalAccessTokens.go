@@ -0,0 +1,22 @@
+package auth
+
+import "strings"
+
+// PersonalAccessTokenPrefix marks personal access tokens, so they can be
+// told apart from JWTs and recognized by secret scanners.
+const PersonalAccessTokenPrefix = "chirpy_pat_"
+
+// MakePersonalAccessToken returns a new personal access token together with
+// the digest from HashToken, which is what gets stored.
+func MakePersonalAccessToken() (token string, digest string, err error) {
+	random, _, err := MakeRefreshToken()
+	if err != nil {
+		return "", "", err
+	}
+	token = PersonalAccessTokenPrefix + random
+	return token, HashToken(token), nil
+}
+
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestMakePersonalAccessToken(t *testing.T) {
+	token, digest, err := MakePersonalAccessToken()
+	if err != nil {
+		t.Fatalf("Error making personal access token: %s", err)
+	}
+	if !IsPersonalAccessToken(token) {
+		t.Errorf("Expected '%s' to be recognized as a personal access token", token)
+	}
+	if len(token) != len(PersonalAccessTokenPrefix)+64 {
+		t.Errorf("Unexpected token length for '%s'", token)
+	}
+	if digest != HashToken(token) {
+		t.Errorf("Expected digest to be '%s', got '%s'", HashToken(token), digest)
+	}
+	jwt, err := MakeJWT(uuid.Max, "secret", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsPersonalAccessToken(jwt) {
+		t.Error("Expected a JWT not to be recognized as a personal access token")
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"encoding/hex"
 )
 
-func MakeRefreshToken() (string, error) {
+// MakeRefreshToken returns a random token for the client together with the
+// digest from HashToken, which is what gets stored.
+func MakeRefreshToken() (token string, digest string, err error) {
 	b := make([]byte, 32)
-	_, err := rand.Read(b)
+	_, err = rand.Read(b)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
-	return hex.EncodeToString(b), nil
+	token = hex.EncodeToString(b)
+	return token, HashToken(token), nil
 }
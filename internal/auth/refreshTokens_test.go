@@ -0,0 +1,20 @@
+package auth
+
+import "testing"
+
+func TestMakeRefreshToken(t *testing.T) {
+	token, digest, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("Error making refresh token: %s", err)
+	}
+	if len(token) != 64 {
+		t.Errorf("Expected a 64 character token, got '%s'", token)
+	}
+	if digest != HashToken(token) {
+		t.Errorf("Expected digest to be '%s', got '%s'", HashToken(token), digest)
+	}
+	other, _, _ := MakeRefreshToken()
+	if token == other {
+		t.Error("Expected two refresh tokens to differ")
+	}
+}
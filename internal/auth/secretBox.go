@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+var errSealedTooShort = errors.New("sealed secret is too short")
+
+// SecretBox encrypts secrets the server has to read back later, such as TOTP
+// seeds, with AES-256-GCM so a copy of the database alone doesn't reveal
+// them.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// ParseSecretBoxKey decodes a hex encoded 32 byte key, as printed by
+// `openssl rand -hex 32`.
+func ParseSecretBoxKey(s string) ([]byte, error) {
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key is %d bytes, want 32", len(key))
+	}
+	return key, nil
+}
+
+func NewSecretBox(key []byte) (*SecretBox, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+// Seal encrypts secret under a fresh nonce. The same context has to be passed
+// to Open, which ties the result to e.g. the row it is stored in.
+func (b *SecretBox) Seal(secret string, context []byte) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(secret), context)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (b *SecretBox) Open(sealed string, context []byte) (string, error) {
+	dat, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	if len(dat) < b.aead.NonceSize() {
+		return "", errSealedTooShort
+	}
+	nonce, ciphertext := dat[:b.aead.NonceSize()], dat[b.aead.NonceSize():]
+	secret, err := b.aead.Open(nil, nonce, ciphertext, context)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
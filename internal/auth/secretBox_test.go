@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSecretBox(t *testing.T) {
+	key, err := ParseSecretBoxKey(strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatalf("Error parsing key: %s", err)
+	}
+	box, err := NewSecretBox(key)
+	if err != nil {
+		t.Fatalf("Error creating secret box: %s", err)
+	}
+	sealed, err := box.Seal("JBSWY3DPEHPK3PXP", []byte("user-1"))
+	if err != nil {
+		t.Fatalf("Error sealing: %s", err)
+	}
+	if strings.Contains(sealed, "JBSWY3DPEHPK3PXP") {
+		t.Error("Expected the sealed secret not to contain the plaintext")
+	}
+	again, _ := box.Seal("JBSWY3DPEHPK3PXP", []byte("user-1"))
+	if sealed == again {
+		t.Error("Expected two seals of the same secret to differ")
+	}
+	secret, err := box.Open(sealed, []byte("user-1"))
+	if err != nil {
+		t.Fatalf("Error opening: %s", err)
+	}
+	if secret != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Expected secret to be 'JBSWY3DPEHPK3PXP', got '%s'", secret)
+	}
+
+	if _, err := box.Open(sealed, []byte("user-2")); err == nil {
+		t.Error("Expected opening with another context to fail")
+	}
+	otherBox, _ := NewSecretBox([]byte(strings.Repeat("k", 32)))
+	if _, err := otherBox.Open(sealed, []byte("user-1")); err == nil {
+		t.Error("Expected opening with another key to fail")
+	}
+	if _, err := box.Open("c2hvcnQ=", []byte("user-1")); err == nil {
+		t.Error("Expected a truncated secret to be rejected")
+	}
+}
+
+func TestParseSecretBoxKey(t *testing.T) {
+	for _, s := range []string{"", "not hex", strings.Repeat("ab", 16)} {
+		if _, err := ParseSecretBoxKey(s); err == nil {
+			t.Errorf("Expected key %q to be rejected", s)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test vectors from RFC 6238 Appendix B.
+func TestTOTPRFC6238(t *testing.T) {
+	keys := map[string][]byte{
+		"SHA1":   []byte("12345678901234567890"),
+		"SHA256": []byte("12345678901234567890123456789012"),
+		"SHA512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	hashes := map[string]func() hash.Hash{
+		"SHA1":   sha1.New,
+		"SHA256": sha256.New,
+		"SHA512": sha512.New,
+	}
+	tests := []struct {
+		unix int64
+		alg  string
+		want string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111109, "SHA256", "68084774"},
+		{1111111109, "SHA512", "25091201"},
+		{1111111111, "SHA1", "14050471"},
+		{1111111111, "SHA256", "67062674"},
+		{1111111111, "SHA512", "99943326"},
+		{1234567890, "SHA1", "89005924"},
+		{1234567890, "SHA256", "91819424"},
+		{1234567890, "SHA512", "93441116"},
+		{2000000000, "SHA1", "69279037"},
+		{2000000000, "SHA256", "90698825"},
+		{2000000000, "SHA512", "38618901"},
+		{20000000000, "SHA1", "65353130"},
+		{20000000000, "SHA256", "77737706"},
+		{20000000000, "SHA512", "47863826"},
+	}
+	for _, tt := range tests {
+		step := totpStep(time.Unix(tt.unix, 0), 30*time.Second)
+		if got := hotp(keys[tt.alg], uint64(step), 8, hashes[tt.alg]); got != tt.want {
+			t.Errorf("TOTP %s at %d: expected %s, got %s", tt.alg, tt.unix, tt.want, got)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+	code := hotp([]byte("12345678901234567890"), uint64(totpStep(now, totpPeriod)), totpDigits, sha1.New)
+
+	tests := []struct {
+		name string
+		code string
+		at   time.Time
+		ok   bool
+	}{
+		{"current step", code, now, true},
+		{"next step", code, now.Add(totpPeriod), true},
+		{"previous step", code, now.Add(-totpPeriod), true},
+		{"too old", code, now.Add(3 * totpPeriod), false},
+		{"wrong code", "000000", now, false},
+		{"wrong length", code[:5], now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(secret, tt.code, tt.at)
+			if ok != tt.ok {
+				t.Fatalf("Expected ok to be %v, got %v", tt.ok, ok)
+			}
+			if ok && step != totpStep(now, totpPeriod) {
+				t.Errorf("Expected step %d, got %d", totpStep(now, totpPeriod), step)
+			}
+		})
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("JBSWY3DPEHPK3PXP", "Chirpy", "alice@example.com")
+	want := "otpauth://totp/Chirpy:alice@example.com?algorithm=SHA1&digits=6&issuer=Chirpy&period=30&secret=JBSWY3DPEHPK3PXP"
+	if uri != want {
+		t.Errorf("Expected %s, got %s", want, uri)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("Unexpected recovery code format %q", code)
+		}
+		if seen[code] {
+			t.Errorf("Duplicate recovery code %q", code)
+		}
+		seen[code] = true
+		typed := strings.ToUpper(strings.ReplaceAll(code, "-", " "))
+		if got := NormalizeRecoveryCode(typed); got != code {
+			t.Errorf("Expected %q to normalize to %q, got %q", typed, code, got)
+		}
+	}
+}
@@ -7,24 +7,57 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
 INSERT INTO
-    chirps (id, created_at, updated_at, user_id, body)
+    chirps (
+        id,
+        created_at,
+        updated_at,
+        user_id,
+        body,
+        in_reply_to,
+        rechirp_of,
+        quote_of,
+        is_quote
+    )
 VALUES
-    (gen_random_uuid(), NOW(), NOW(), $1, $2) RETURNING id, created_at, updated_at, user_id, body
+    (
+        gen_random_uuid(),
+        NOW(),
+        NOW(),
+        $1,
+        $2,
+        $3,
+        $4,
+        $5,
+        $6
+    ) RETURNING id, created_at, updated_at, user_id, body, edited_at, in_reply_to, reply_count, like_count, rechirp_of, quote_of, is_quote
 `
 
 type CreateChirpParams struct {
-	UserID uuid.UUID
-	Body   string
+	UserID    uuid.UUID
+	Body      string
+	InReplyTo uuid.NullUUID
+	RechirpOf uuid.NullUUID
+	QuoteOf   uuid.NullUUID
+	IsQuote   bool
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.UserID, arg.Body)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.UserID,
+		arg.Body,
+		arg.InReplyTo,
+		arg.RechirpOf,
+		arg.QuoteOf,
+		arg.IsQuote,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -32,6 +65,13 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.Body,
+		&i.EditedAt,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.IsQuote,
 	)
 	return i, err
 }
@@ -50,11 +90,13 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 
 const getChirp = `-- name: GetChirp :one
 SELECT
-    chirps.id, chirps.created_at, chirps.updated_at, chirps.user_id, chirps.body
+    chirps.id, chirps.created_at, chirps.updated_at, chirps.user_id, chirps.body, chirps.edited_at, chirps.in_reply_to, chirps.reply_count, chirps.like_count, chirps.rechirp_of, chirps.quote_of, chirps.is_quote
 FROM
     chirps
+    JOIN users ON users.id = chirps.user_id
 WHERE
-    id = $1
+    chirps.id = $1
+    AND users.deleted_at IS NULL
 `
 
 func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -66,28 +108,51 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.Body,
+		&i.EditedAt,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.IsQuote,
 	)
 	return i, err
 }
 
-const getChirps = `-- name: GetChirps :many
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT
+        chirps.id,
+        chirps.in_reply_to,
+        0 AS depth
+    FROM
+        chirps
+    WHERE
+        chirps.id = $1::uuid
+    UNION ALL
+    SELECT
+        parent.id,
+        parent.in_reply_to,
+        ancestors.depth + 1
+    FROM
+        chirps parent
+        JOIN ancestors ON parent.id = ancestors.in_reply_to
+)
 SELECT
-    chirps.id, chirps.created_at, chirps.updated_at, chirps.user_id, chirps.body
+    chirps.id, chirps.created_at, chirps.updated_at, chirps.user_id, chirps.body, chirps.edited_at, chirps.in_reply_to, chirps.reply_count, chirps.like_count, chirps.rechirp_of, chirps.quote_of, chirps.is_quote
 FROM
     chirps
+    JOIN ancestors ON chirps.id = ancestors.id
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    ancestors.depth > 0
+    AND users.deleted_at IS NULL
 ORDER BY
-    CASE
-        WHEN $1 = 'asc' THEN created_at
-        ELSE NULL
-    END ASC,
-    CASE
-        WHEN $1 = 'desc' THEN created_at
-        ELSE NULL
-    END DESC
+    ancestors.depth DESC
 `
 
-func (q *Queries) GetChirps(ctx context.Context, dollar_1 interface{}) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps, dollar_1)
+func (q *Queries) GetChirpAncestors(ctx context.Context, chirpID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, chirpID)
 	if err != nil {
 		return nil, err
 	}
@@ -101,6 +166,13 @@ func (q *Queries) GetChirps(ctx context.Context, dollar_1 interface{}) ([]Chirp,
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.Body,
+			&i.EditedAt,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.IsQuote,
 		); err != nil {
 			return nil, err
 		}
@@ -115,28 +187,130 @@ func (q *Queries) GetChirps(ctx context.Context, dollar_1 interface{}) ([]Chirp,
 	return items, nil
 }
 
-const getChirpsByUser = `-- name: GetChirpsByUser :many
-SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.user_id, chirps.body
-FROM chirps
-WHERE user_id = $1
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT
+        chirps.id
+    FROM
+        chirps
+    WHERE
+        chirps.in_reply_to = $1::uuid
+    UNION ALL
+    SELECT
+        reply.id
+    FROM
+        chirps reply
+        JOIN descendants ON reply.in_reply_to = descendants.id
+)
+SELECT
+    chirps.id, chirps.created_at, chirps.updated_at, chirps.user_id, chirps.body, chirps.edited_at, chirps.in_reply_to, chirps.reply_count, chirps.like_count, chirps.rechirp_of, chirps.quote_of, chirps.is_quote
+FROM
+    chirps
+    JOIN descendants ON chirps.id = descendants.id
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND (
+        $2::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) > ($2, $3::uuid)
+    )
 ORDER BY
-    CASE
-        WHEN $2 = 'asc' THEN created_at
-        ELSE NULL
-    END ASC,
-    CASE
-        WHEN $2 = 'desc' THEN created_at
-        ELSE NULL
-    END DESC
+    chirps.created_at ASC,
+    chirps.id ASC
+LIMIT
+    $4
+`
+
+type GetChirpDescendantsParams struct {
+	ChirpID         uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants,
+		arg.ChirpID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.EditedAt,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.IsQuote,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT
+    chirps.id, chirps.created_at, chirps.updated_at, chirps.user_id, chirps.body, chirps.edited_at, chirps.in_reply_to, chirps.reply_count, chirps.like_count, chirps.rechirp_of, chirps.quote_of, chirps.is_quote
+FROM
+    chirps
+WHERE
+    id = $1 FOR UPDATE
 `
 
-type GetChirpsByUserParams struct {
-	UserID  uuid.UUID
-	Column2 interface{}
+func (q *Queries) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.EditedAt,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.IsQuote,
+	)
+	return i, err
 }
 
-func (q *Queries) GetChirpsByUser(ctx context.Context, arg GetChirpsByUserParams) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUser, arg.UserID, arg.Column2)
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT
+    chirps.id, chirps.created_at, chirps.updated_at, chirps.user_id, chirps.body, chirps.edited_at, chirps.in_reply_to, chirps.reply_count, chirps.like_count, chirps.rechirp_of, chirps.quote_of, chirps.is_quote
+FROM
+    chirps
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND chirps.id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(ids))
 	if err != nil {
 		return nil, err
 	}
@@ -150,6 +324,13 @@ func (q *Queries) GetChirpsByUser(ctx context.Context, arg GetChirpsByUserParams
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.Body,
+			&i.EditedAt,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.IsQuote,
 		); err != nil {
 			return nil, err
 		}
@@ -163,3 +344,260 @@ func (q *Queries) GetChirpsByUser(ctx context.Context, arg GetChirpsByUserParams
 	}
 	return items, nil
 }
+
+const getChirpsPageAsc = `-- name: GetChirpsPageAsc :many
+SELECT
+    chirps.id, chirps.created_at, chirps.updated_at, chirps.user_id, chirps.body, chirps.edited_at, chirps.in_reply_to, chirps.reply_count, chirps.like_count, chirps.rechirp_of, chirps.quote_of, chirps.is_quote
+FROM
+    chirps
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND ($1::uuid IS NULL OR chirps.user_id = $1)
+    AND (
+        $2::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) > ($2, $3::uuid)
+    )
+ORDER BY
+    chirps.created_at ASC,
+    chirps.id ASC
+LIMIT
+    $4
+`
+
+type GetChirpsPageAscParams struct {
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) GetChirpsPageAsc(ctx context.Context, arg GetChirpsPageAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsPageAsc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.EditedAt,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.IsQuote,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsPageDesc = `-- name: GetChirpsPageDesc :many
+SELECT
+    chirps.id, chirps.created_at, chirps.updated_at, chirps.user_id, chirps.body, chirps.edited_at, chirps.in_reply_to, chirps.reply_count, chirps.like_count, chirps.rechirp_of, chirps.quote_of, chirps.is_quote
+FROM
+    chirps
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND ($1::uuid IS NULL OR chirps.user_id = $1)
+    AND (
+        $2::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) < ($2, $3::uuid)
+    )
+ORDER BY
+    chirps.created_at DESC,
+    chirps.id DESC
+LIMIT
+    $4
+`
+
+type GetChirpsPageDescParams struct {
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) GetChirpsPageDesc(ctx context.Context, arg GetChirpsPageDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsPageDesc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.EditedAt,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.IsQuote,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT
+    chirps.id, chirps.created_at, chirps.updated_at, chirps.user_id, chirps.body, chirps.edited_at, chirps.in_reply_to, chirps.reply_count, chirps.like_count, chirps.rechirp_of, chirps.quote_of, chirps.is_quote,
+    ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', $1::text)) AS rank
+FROM
+    chirps
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND to_tsvector('english', chirps.body) @@ websearch_to_tsquery('english', $1::text)
+    AND ($2::uuid IS NULL OR chirps.user_id = $2)
+    AND ($3::timestamp IS NULL OR chirps.created_at >= $3)
+    AND ($4::timestamp IS NULL OR chirps.created_at < $4)
+    AND (
+        $5::real IS NULL
+        OR (ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', $1::text)), chirps.id) < ($5, $6::uuid)
+    )
+ORDER BY
+    rank DESC,
+    chirps.id DESC
+LIMIT
+    $7
+`
+
+type SearchChirpsParams struct {
+	Query         string
+	AuthorID      uuid.NullUUID
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	CursorRank    sql.NullFloat64
+	CursorID      uuid.NullUUID
+	PageLimit     int32
+}
+
+type SearchChirpsRow struct {
+	Chirp Chirp
+	Rank  float32
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.AuthorID,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.CursorRank,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.UserID,
+			&i.Chirp.Body,
+			&i.Chirp.EditedAt,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.LikeCount,
+			&i.Chirp.RechirpOf,
+			&i.Chirp.QuoteOf,
+			&i.Chirp.IsQuote,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE
+    chirps
+SET
+    body = $1,
+    edited_at = NOW(),
+    updated_at = NOW()
+WHERE
+    id = $2
+RETURNING id, created_at, updated_at, user_id, body, edited_at, in_reply_to, reply_count, like_count, rechirp_of, quote_of, is_quote
+`
+
+type UpdateChirpBodyParams struct {
+	Body string
+	ID   uuid.UUID
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.Body, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.EditedAt,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.IsQuote,
+	)
+	return i, err
+}
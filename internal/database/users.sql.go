@@ -7,8 +7,10 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -18,7 +20,8 @@ INSERT INTO
         created_at,
         updated_at,
         email,
-        hashed_password
+        hashed_password,
+        handle
     )
 VALUES
     (
@@ -26,17 +29,19 @@ VALUES
         NOW(),
         NOW(),
         $1,
-        $2
-    ) RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+        $2,
+        $3
+    ) RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, email_verified_at, deleted_at, token_version
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -45,13 +50,32 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM
+    users
+WHERE
+    id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
 SELECT
-    users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red
+    users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red, users.handle, users.display_name, users.bio, users.location, users.email_verified_at, users.deleted_at, users.token_version
 FROM
     users
 WHERE
@@ -68,13 +92,50 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.TokenVersion,
+	)
+	return i, err
+}
+
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT
+    users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red, users.handle, users.display_name, users.bio, users.location, users.email_verified_at, users.deleted_at, users.token_version
+FROM
+    users
+WHERE
+    lower(handle) = lower($1)
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
 SELECT
-    users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red
+    users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red, users.handle, users.display_name, users.bio, users.location, users.email_verified_at, users.deleted_at, users.token_version
 FROM
     users
 WHERE
@@ -91,10 +152,187 @@ func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.TokenVersion,
+	)
+	return i, err
+}
+
+const getUserProfileCounts = `-- name: GetUserProfileCounts :one
+SELECT
+    (
+        SELECT
+            COUNT(*)
+        FROM
+            follows
+            JOIN users ON users.id = follows.follower_id
+        WHERE
+            users.deleted_at IS NULL
+            AND follows.followee_id = $1
+    ) AS follower_count,
+    (
+        SELECT
+            COUNT(*)
+        FROM
+            follows
+            JOIN users ON users.id = follows.followee_id
+        WHERE
+            users.deleted_at IS NULL
+            AND follows.follower_id = $1
+    ) AS following_count,
+    (
+        SELECT
+            COUNT(*)
+        FROM
+            chirps
+        WHERE
+            chirps.user_id = $1
+    ) AS chirp_count
+`
+
+type GetUserProfileCountsRow struct {
+	FollowerCount  int64
+	FollowingCount int64
+	ChirpCount     int64
+}
+
+func (q *Queries) GetUserProfileCounts(ctx context.Context, userID uuid.UUID) (GetUserProfileCountsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserProfileCounts, userID)
+	var i GetUserProfileCountsRow
+	err := row.Scan(
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.ChirpCount,
+	)
+	return i, err
+}
+
+const getUsersByHandles = `-- name: GetUsersByHandles :many
+SELECT
+    id,
+    handle
+FROM
+    users
+WHERE
+    lower(handle) = ANY($1::text[])
+    AND deleted_at IS NULL
+`
+
+type GetUsersByHandlesRow struct {
+	ID     uuid.UUID
+	Handle string
+}
+
+func (q *Queries) GetUsersByHandles(ctx context.Context, handles []string) ([]GetUsersByHandlesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByHandles, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersByHandlesRow
+	for rows.Next() {
+		var i GetUsersByHandlesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Handle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const incrementUserTokenVersion = `-- name: IncrementUserTokenVersion :one
+UPDATE
+    users
+SET
+    token_version = token_version + 1,
+    updated_at = NOW()
+WHERE
+    id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, email_verified_at, deleted_at, token_version
+`
+
+func (q *Queries) IncrementUserTokenVersion(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, incrementUserTokenVersion, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
+const markUserEmailVerified = `-- name: MarkUserEmailVerified :one
+UPDATE
+    users
+SET
+    email_verified_at = COALESCE(email_verified_at, NOW()),
+    updated_at = NOW()
+WHERE
+    id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, email_verified_at, deleted_at, token_version
+`
+
+func (q *Queries) MarkUserEmailVerified(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, markUserEmailVerified, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.TokenVersion,
+	)
+	return i, err
+}
+
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :execrows
+DELETE FROM
+    users
+WHERE
+    deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedUsers(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedUsers, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const purgeUsers = `-- name: PurgeUsers :exec
 DELETE FROM
     users
@@ -105,6 +343,74 @@ func (q *Queries) PurgeUsers(ctx context.Context) error {
 	return err
 }
 
+const rehashUserPassword = `-- name: RehashUserPassword :exec
+UPDATE
+    users
+SET
+    hashed_password = $1
+WHERE
+    id = $2
+    AND hashed_password = $3
+`
+
+type RehashUserPasswordParams struct {
+	NewHashedPassword string
+	ID                uuid.UUID
+	OldHashedPassword string
+}
+
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, rehashUserPassword, arg.NewHashedPassword, arg.ID, arg.OldHashedPassword)
+	return err
+}
+
+const restoreUser = `-- name: RestoreUser :one
+UPDATE
+    users
+SET
+    deleted_at = NULL,
+    updated_at = NOW()
+WHERE
+    id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, email_verified_at, deleted_at, token_version
+`
+
+func (q *Queries) RestoreUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, restoreUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.TokenVersion,
+	)
+	return i, err
+}
+
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :exec
+UPDATE
+    users
+SET
+    deleted_at = NOW(),
+    updated_at = NOW()
+WHERE
+    id = $1
+`
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, scheduleUserDeletion, id)
+	return err
+}
+
 const updateIsChirpyRed = `-- name: UpdateIsChirpyRed :one
 UPDATE
     users
@@ -113,7 +419,7 @@ SET
     updated_at = NOW()
 WHERE
     id = $2
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, email_verified_at, deleted_at, token_version
 `
 
 type UpdateIsChirpyRedParams struct {
@@ -131,30 +437,122 @@ func (q *Queries) UpdateIsChirpyRed(ctx context.Context, arg UpdateIsChirpyRedPa
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
-const updateUser = `-- name: UpdateUser :one
+const updateUserEmail = `-- name: UpdateUserEmail :one
 UPDATE
     users
 SET
     email = $1,
-    hashed_password = $2,
+    email_verified_at = NOW(),
     updated_at = NOW()
 WHERE
-    id = $3
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+    id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, email_verified_at, deleted_at, token_version
 `
 
-type UpdateUserParams struct {
-	Email          string
+type UpdateUserEmailParams struct {
+	Email string
+	ID    uuid.UUID
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserEmail, arg.Email, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.TokenVersion,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE
+    users
+SET
+    hashed_password = $1,
+    updated_at = NOW()
+WHERE
+    id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, email_verified_at, deleted_at, token_version
+`
+
+type UpdateUserPasswordParams struct {
 	HashedPassword string
 	ID             uuid.UUID
 }
 
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUser, arg.Email, arg.HashedPassword, arg.ID)
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.HashedPassword, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.TokenVersion,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE
+    users
+SET
+    handle = COALESCE($1, handle),
+    display_name = COALESCE($2, display_name),
+    bio = COALESCE($3, bio),
+    location = COALESCE($4, location),
+    updated_at = NOW()
+WHERE
+    id = $5
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, email_verified_at, deleted_at, token_version
+`
+
+type UpdateUserProfileParams struct {
+	Handle      sql.NullString
+	DisplayName sql.NullString
+	Bio         sql.NullString
+	Location    sql.NullString
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.Location,
+		arg.ID,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -163,6 +561,13 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.EmailVerifiedAt,
+		&i.DeletedAt,
+		&i.TokenVersion,
 	)
 	return i, err
 }
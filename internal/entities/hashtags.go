@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const maxHashtagLength = 100
+
+// A hashtag starts at the beginning of the text or after a character that
+// cannot be part of a word, so "a#b" and "&#123;" are not tags.
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#])#([\p{L}\p{N}_]+)`)
+
+// ExtractHashtags returns the distinct hashtags in text, lower-cased and
+// without the leading '#', in order of first appearance. Tags made only of
+// digits or underscores are ignored.
+func ExtractHashtags(text string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		tag := strings.ToLower(match[1])
+		if len(tag) > maxHashtagLength || !strings.ContainsFunc(tag, unicode.IsLetter) || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// NormalizeHashtag turns user input such as "#Go" into the stored form "go".
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
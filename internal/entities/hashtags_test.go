@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"no tags here", nil},
+		{"#Go is fun", []string{"go"}},
+		{"learning #golang and #Postgres, #golang again", []string{"golang", "postgres"}},
+		{"mail a#b or &#123; are not tags", nil},
+		{"#2024 needs a letter but #year2024 is fine", []string{"year2024"}},
+		{"(#paren) #żółw", []string{"paren", "żółw"}},
+	}
+	for _, tt := range tests {
+		got := ExtractHashtags(tt.text)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ExtractHashtags(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	if got := NormalizeHashtag("#GoLang"); got != "golang" {
+		t.Errorf("Expected 'golang', got '%s'", got)
+	}
+}
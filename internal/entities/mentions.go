@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const MaxHandleLength = 30
+
+// HandlePattern matches a complete, valid handle.
+var HandlePattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,30}$`)
+
+// reservedHandles are path words under /api/users, where a handle can stand
+// in the same place, and names that would let someone pass as staff.
+var reservedHandles = []string{"me", "email", "verify", "admin", "api", "chirpy"}
+
+// IsReservedHandle reports whether handle is kept from users, whatever its
+// case.
+func IsReservedHandle(handle string) bool {
+	return slices.Contains(reservedHandles, strings.ToLower(handle))
+}
+
+// A mention starts at the beginning of the text or after a character that
+// cannot be part of a handle or an email address, so "me@example.com" is
+// not a mention of @example.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.])@([A-Za-z0-9_]+)`)
+
+// Mention is an @handle found in a text. Start and End are offsets in
+// Unicode code points, Start at the '@' and End exclusive.
+type Mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// ExtractMentions returns every @handle in text in order of appearance.
+// The same handle may appear more than once.
+func ExtractMentions(text string) []Mention {
+	var mentions []Mention
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		handleStart, handleEnd := match[2], match[3]
+		if handleEnd-handleStart > MaxHandleLength {
+			continue
+		}
+		start := utf8.RuneCountInString(text[:handleStart-1])
+		mentions = append(mentions, Mention{
+			Handle: text[handleStart:handleEnd],
+			Start:  start,
+			End:    start + 1 + (handleEnd - handleStart),
+		})
+	}
+	return mentions
+}
@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		text string
+		want []Mention
+	}{
+		{"nobody here", nil},
+		{"@alice hi", []Mention{{Handle: "alice", Start: 0, End: 6}}},
+		{"hi @Bob_1 and @alice, also @bob_1", []Mention{
+			{Handle: "Bob_1", Start: 3, End: 9},
+			{Handle: "alice", Start: 14, End: 20},
+			{Handle: "bob_1", Start: 27, End: 33},
+		}},
+		{"write to me@example.com", nil},
+		{"żółw @alice", []Mention{{Handle: "alice", Start: 5, End: 11}}},
+		{"@abcdefghijklmnopqrstuvwxyz12345 is too long", nil},
+	}
+	for _, tt := range tests {
+		got := ExtractMentions(tt.text)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ExtractMentions(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHandlePattern(t *testing.T) {
+	for _, handle := range []string{"alice", "Bob_1", "_"} {
+		if !HandlePattern.MatchString(handle) {
+			t.Errorf("Expected %q to be a valid handle", handle)
+		}
+	}
+	for _, handle := range []string{"", "with space", "dash-ed", "abcdefghijklmnopqrstuvwxyz12345"} {
+		if HandlePattern.MatchString(handle) {
+			t.Errorf("Expected %q to be an invalid handle", handle)
+		}
+	}
+}
+
+func TestIsReservedHandle(t *testing.T) {
+	for _, handle := range []string{"me", "Me", "ADMIN", "verify"} {
+		if !IsReservedHandle(handle) {
+			t.Errorf("Expected %q to be reserved", handle)
+		}
+	}
+	for _, handle := range []string{"alice", "meme", "admin_bob"} {
+		if IsReservedHandle(handle) {
+			t.Errorf("Expected %q not to be reserved", handle)
+		}
+	}
+}
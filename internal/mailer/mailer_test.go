@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m := &FileMailer{Dir: dir, From: "chirpy@localhost"}
+	err := m.Send(context.Background(), Message{
+		To:      "user@example.com",
+		Subject: "Hello",
+		Body:    "line one\nline two",
+	})
+	if err != nil {
+		t.Fatalf("Error sending message: %s", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected one .eml file, got %v (%v)", files, err)
+	}
+	dat, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"From: chirpy@localhost\r\n",
+		"To: user@example.com\r\n",
+		"Subject: Hello\r\n",
+		"\r\n\r\nline one\r\nline two",
+	} {
+		if !strings.Contains(string(dat), want) {
+			t.Errorf("Expected message to contain %q, got:\n%s", want, dat)
+		}
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	m := &MemoryMailer{}
+	msg := Message{To: "user@example.com", Subject: "Hello", Body: "Hi"}
+	if err := m.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Error sending message: %s", err)
+	}
+	sent := m.Sent()
+	if len(sent) != 1 || sent[0] != msg {
+		t.Errorf("Expected %v to be recorded, got %v", msg, sent)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.Send(ctx, msg); err == nil {
+		t.Error("Expected error sending with a cancelled context")
+	}
+	if len(m.Sent()) != 1 {
+		t.Error("Expected message sent with a cancelled context to be dropped")
+	}
+}
@@ -0,0 +1,10 @@
+package main
+
+import "net/http"
+
+// handleJWKS publishes the public keys that verify Chirpy access tokens so
+// other services don't need a shared secret.
+func (cfg *apiConfig) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	responseWithJson(cfg.jwtKeys.JWKS(), w, http.StatusOK)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type likeResponse struct {
+	UserID  uuid.UUID `json:"user_id"`
+	LikedAt time.Time `json:"liked_at"`
+}
+
+func (cfg *apiConfig) handleLikeChirp(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	chirp, ok := cfg.loadPathChirp(w, r)
+	if !ok {
+		return
+	}
+	// Liking twice is a no-op: the composite primary key absorbs the repeat.
+	err := cfg.db.CreateChirpLike(r.Context(), database.CreateChirpLikeParams{
+		ChirpID: chirp.ID,
+		UserID:  user.ID,
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	cfg.respondWithLikeState(w, r, chirp.ID, true)
+}
+
+func (cfg *apiConfig) handleUnlikeChirp(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	chirp, ok := cfg.loadPathChirp(w, r)
+	if !ok {
+		return
+	}
+	err := cfg.db.DeleteChirpLike(r.Context(), database.DeleteChirpLikeParams{
+		ChirpID: chirp.ID,
+		UserID:  user.ID,
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	cfg.respondWithLikeState(w, r, chirp.ID, false)
+}
+
+// respondWithLikeState re-reads the chirp so like_count reflects every
+// concurrent like, not just the one made by this request.
+func (cfg *apiConfig) respondWithLikeState(w http.ResponseWriter, r *http.Request, chirpID uuid.UUID, likedByMe bool) {
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	chirpsResponse := []chirpResponse{mapChirpToResponse(&chirp)}
+	if err := cfg.attachOriginals(r.Context(), chirpsResponse); err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	chirpsResponse[0].LikedByMe = &likedByMe
+	responseWithJson(chirpsResponse[0], w, http.StatusOK)
+}
+
+func (cfg *apiConfig) handleGetChirpLikes(w http.ResponseWriter, r *http.Request) {
+	chirp, ok := cfg.loadPathChirp(w, r)
+	if !ok {
+		return
+	}
+	page, err := parsePageRequest(r)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 400)
+		return
+	}
+	rows, err := cfg.db.GetChirpLikes(r.Context(), database.GetChirpLikesParams{
+		ChirpID:         chirp.ID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	likes := make([]likeResponse, 0, len(rows))
+	for _, row := range rows {
+		likes = append(likes, likeResponse{UserID: row.UserID, LikedAt: row.LikedAt})
+	}
+	likes, nextCursor := trimPage(likes, page.Limit, func(like likeResponse) any {
+		return pageCursor{CreatedAt: like.LikedAt, ID: like.UserID}
+	})
+	responseWithJson(pageResponse[likeResponse]{Data: likes, NextCursor: nextCursor}, w, http.StatusOK)
+}
+
+// markLikedByMe fills in liked_by_me when the request carries a valid bearer
+// token, using one query for every chirp passed in. Anonymous requests leave
+// the field out of the response.
+func (cfg *apiConfig) markLikedByMe(r *http.Request, chirps ...[]chirpResponse) error {
+	viewerID, ok := cfg.viewerID(r)
+	if !ok {
+		return nil
+	}
+	var chirpIDs []uuid.UUID
+	for _, list := range chirps {
+		for _, chirp := range list {
+			chirpIDs = append(chirpIDs, chirp.ID)
+		}
+	}
+	if len(chirpIDs) == 0 {
+		return nil
+	}
+	likedIDs, err := cfg.db.GetLikedChirpIDs(r.Context(), database.GetLikedChirpIDsParams{
+		UserID:   viewerID,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return err
+	}
+	liked := make(map[uuid.UUID]bool, len(likedIDs))
+	for _, id := range likedIDs {
+		liked[id] = true
+	}
+	for _, list := range chirps {
+		for i := range list {
+			likedByMe := liked[list[i].ID]
+			list[i].LikedByMe = &likedByMe
+		}
+	}
+	return nil
+}
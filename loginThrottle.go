@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/auth"
+)
+
+var (
+	// loginAccountPolicy protects a single account from password guessing.
+	loginAccountPolicy = auth.LimiterPolicy{
+		FreeFailures: 5,
+		BaseDelay:    time.Second,
+		LockoutAfter: 10,
+		Lockout:      15 * time.Minute,
+		Window:       time.Hour,
+	}
+	// loginIPPolicy is looser, since many users can share an address, but
+	// stops one client from spreading guesses across many accounts.
+	loginIPPolicy = auth.LimiterPolicy{
+		FreeFailures: 20,
+		BaseDelay:    time.Second,
+		LockoutAfter: 100,
+		Lockout:      time.Hour,
+		Window:       time.Hour,
+	}
+)
+
+const invalidLoginMessage = "Invalid email or password"
+
+// allowLogin answers 429 with a Retry-After header, and returns false, when
+// the account or the client address has failed too often lately. Otherwise
+// the attempt counts as failed until loginSucceeded says it wasn't. Accounts
+// are keyed by the email as typed, so unknown emails are throttled the same
+// as real ones.
+func (cfg *apiConfig) allowLogin(w http.ResponseWriter, account, ip string) bool {
+	wait, ok := cfg.loginIPs.Allow(ip)
+	if ok {
+		if wait, ok = cfg.loginAccounts.Allow(account); !ok {
+			cfg.loginIPs.Release(ip)
+		}
+	}
+	if ok {
+		return true
+	}
+	responseWithTooManyRequests(w, "Too many failed login attempts, try again later", wait)
+	return false
+}
+
+// responseWithTooManyRequests answers 429 with a Retry-After header.
+func responseWithTooManyRequests(w http.ResponseWriter, msg string, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(retryAfter.Seconds()))))
+	responseWithJsonError(w, msg, http.StatusTooManyRequests)
+}
+
+// loginSucceeded takes back the attempt allowLogin counted. The account
+// starts over, but the address keeps its earlier failures, so logging in to
+// one account doesn't clear guesses at others.
+func (cfg *apiConfig) loginSucceeded(account, ip string) {
+	cfg.loginAccounts.Reset(account)
+	cfg.loginIPs.Release(ip)
+}
+
+// loginErrored takes back the attempt allowLogin counted when the login
+// couldn't be checked at all, since a server error says nothing about the
+// credentials.
+func (cfg *apiConfig) loginErrored(account, ip string) {
+	cfg.loginAccounts.Release(account)
+	cfg.loginIPs.Release(ip)
+}
+
+// failLogin answers with the same error whether the email or the password
+// was wrong. allowLogin has already counted the attempt.
+func (cfg *apiConfig) failLogin(w http.ResponseWriter) {
+	responseWithJsonError(w, invalidLoginMessage, http.StatusUnauthorized)
+}
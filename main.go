@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"time"
 
+	"github.com/ZDSDD/Chirpy/internal/auth"
 	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/ZDSDD/Chirpy/internal/mailer"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
@@ -27,7 +34,16 @@ func main() {
 	cfg := &apiConfig{
 		fileserverHits: atomic.Int32{},
 		db:             dbQueries,
-		jwtSecret:      getEnvVariable("JWT_SECRET"),
+		dbConn:         db,
+		jwtKeys:        newJWTKeySet(),
+		mailer:         newMailer(),
+		denylist:       auth.NewDenylist(dbDenylistStore{db: dbQueries}),
+		totpSecrets:    newTOTPSecretBox(),
+		loginAccounts:  auth.NewLimiter(loginAccountPolicy),
+		loginIPs:       auth.NewLimiter(loginIPPolicy),
+		resetEmails:    auth.NewLimiter(resetEmailPolicy),
+		resetIPs:       auth.NewLimiter(resetIPPolicy),
+		passwordPolicy: newPasswordPolicy(),
 	}
 
 	server := http.Server{
@@ -45,26 +61,71 @@ func main() {
 	// User-related routes
 	mux.HandleFunc("POST /api/users", cfg.handleCreateUser)
 	mux.HandleFunc("POST /api/login", cfg.handleLogin)
-	mux.HandleFunc("PUT /api/users", cfg.requireBearerToken(cfg.handleUpdateUser))
+	mux.HandleFunc("POST /api/login/mfa", cfg.handleLoginMFA)
+	mux.HandleFunc("PUT /api/users", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleUpdateUser)))
+	mux.HandleFunc("PATCH /api/users", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handlePatchUser)))
+	mux.HandleFunc("POST /api/users/email/confirm", cfg.handleConfirmEmailChange)
+	mux.HandleFunc("POST /api/users/verify", cfg.handleVerifyEmail)
+	mux.HandleFunc("POST /api/users/verify/resend", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleResendEmailVerification)))
+	mux.HandleFunc("POST /api/password/forgot", cfg.handleForgotPassword)
+	mux.HandleFunc("POST /api/password/reset", cfg.handleResetPassword)
 	mux.HandleFunc("POST /api/polka/webhooks", cfg.handleUpgradePolkaUser)
+	mux.HandleFunc("GET /api/users/{handleOrID}", cfg.handleGetUserProfile)
+	mux.HandleFunc("DELETE /api/users/me", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleDeleteMe)))
+	mux.HandleFunc("POST /api/users/me/revoke-tokens", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleRevokeAllTokens)))
+	mux.HandleFunc("GET /api/users/me/export", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleExportMe)))
+	mux.HandleFunc("POST /api/users/me/2fa/totp", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleEnrollTOTP)))
+	mux.HandleFunc("POST /api/users/me/2fa/totp/confirm", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleConfirmTOTP)))
+	mux.HandleFunc("DELETE /api/users/me/2fa/totp", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleDisableTOTP)))
+	mux.HandleFunc("POST /api/users/me/tokens", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleCreatePersonalAccessToken)))
+	mux.HandleFunc("GET /api/users/me/tokens", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleGetPersonalAccessTokens)))
+	mux.HandleFunc("DELETE /api/users/me/tokens/{tokenID}", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleDeletePersonalAccessToken)))
+	mux.HandleFunc("GET /api/users/me/mentions", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeChirpsRead, cfg.handleGetMyMentions)))
+
+	// Follow-related routes
+	mux.HandleFunc("POST /api/users/{userID}/follow", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeFollowsWrite, cfg.handleFollowUser)))
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeFollowsWrite, cfg.handleUnfollowUser)))
+	mux.HandleFunc("GET /api/users/{userID}/followers", cfg.handleGetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", cfg.handleGetFollowing)
+	mux.HandleFunc("GET /api/timeline", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeChirpsRead, cfg.handleGetTimeline)))
 
 	// JWT-related routers
-	mux.HandleFunc("POST /api/refresh", cfg.requireBearerToken(cfg.requireValidJWTToken(cfg.handleRefreshToken)))
+	mux.HandleFunc("GET /.well-known/jwks.json", cfg.handleJWKS)
+	mux.HandleFunc("POST /api/refresh", cfg.requireBearerToken(cfg.handleRefreshToken))
 	mux.HandleFunc("POST /api/revoke", cfg.requireBearerToken(cfg.handleRevokeToken))
+	mux.HandleFunc("POST /api/logout", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleLogout)))
+
+	// Session-related routes
+	mux.HandleFunc("GET /api/sessions", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleGetSessions)))
+	mux.HandleFunc("DELETE /api/sessions/{sessionID}", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleRevokeSession)))
+	mux.HandleFunc("POST /api/sessions/revoke-all-others", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeSessionOnly, cfg.handleRevokeOtherSessions)))
 
 	// Chirps-related routes
-	mux.HandleFunc("POST /api/chirps", cfg.requireBearerToken(cfg.requireValidJWTToken(cfg.handleCreateChirp)))
+	mux.HandleFunc("POST /api/chirps", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeChirpsWrite, cfg.handleCreateChirp)))
 	mux.HandleFunc("GET /api/chirps", cfg.handleGetChirps)
+	mux.HandleFunc("GET /api/chirps/search", cfg.handleSearchChirps)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", cfg.handleGetChirp)
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.requireBearerToken(cfg.requireValidJWTToken(cfg.handleDeleteChirp)))
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeChirpsWrite, cfg.handleUpdateChirp)))
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", cfg.handleGetChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", cfg.handleGetChirpThread)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/like", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeChirpsWrite, cfg.handleLikeChirp)))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeChirpsWrite, cfg.handleUnlikeChirp)))
+	mux.HandleFunc("GET /api/chirps/{chirpID}/likes", cfg.handleGetChirpLikes)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.requireBearerToken(cfg.requireValidJWTToken(scopeChirpsWrite, cfg.handleDeleteChirp)))
 	mux.HandleFunc("POST /api/validate_chirp", validateChirp)
 
+	// Hashtag-related routes
+	mux.HandleFunc("GET /api/hashtags/trending", cfg.handleGetTrendingHashtags)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", cfg.handleGetHashtagChirps)
+
 	// Admin-related routes
 	mux.HandleFunc("POST /admin/reset", cfg.handleReset)
 
 	// Miscellaneous routes
 	mux.HandleFunc("POST /api/reset", cfg.handleReset)
 
+	go cfg.purgeExpired(context.Background(), purgeInterval)
+
 	// Start the server
 	log.Printf("Server running successfully on port: %s\n", port)
 	log.Fatal(server.ListenAndServe())
@@ -76,6 +137,132 @@ func handleHealthz(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("OK\n"))
 }
 
+// newMailer sends through SMTP_ADDR when it is set and otherwise writes
+// messages to MAIL_DIR so nothing leaves the machine. The default directory
+// lives outside the working directory, which is served under /app/.
+func newMailer() mailer.Mailer {
+	from := getEnvVariable("MAIL_FROM")
+	if from == "" {
+		from = "chirpy@localhost"
+	}
+	if addr := getEnvVariable("SMTP_ADDR"); addr != "" {
+		return &mailer.SMTPMailer{
+			Addr:     addr,
+			From:     from,
+			Username: getEnvVariable("SMTP_USERNAME"),
+			Password: getEnvVariable("SMTP_PASSWORD"),
+		}
+	}
+	dir := getEnvVariable("MAIL_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "chirpy-mail")
+	}
+	log.Printf("SMTP_ADDR not set, writing outgoing mail to %s/", dir)
+	return &mailer.FileMailer{Dir: dir, From: from}
+}
+
+// newJWTKeySet signs with the PEM key at JWT_SIGNING_KEY and also accepts the
+// comma separated JWT_VERIFICATION_KEYS, typically the keys being rotated out.
+// Without a signing key tokens are signed with HS256 and JWT_SECRET, which is
+// otherwise still accepted for tokens issued before the switch.
+func newJWTKeySet() *auth.KeySet {
+	secret := getEnvVariable("JWT_SECRET")
+	signingPath := getEnvVariable("JWT_SIGNING_KEY")
+	if signingPath == "" {
+		keys := auth.NewHMACKeySet(secret)
+		keys.SetPolicy(newJWTPolicy())
+		return keys
+	}
+	signing, err := auth.LoadKeyFile(signingPath)
+	if err != nil {
+		log.Fatalf("Error loading JWT signing key: %s", err)
+	}
+	var verification []*auth.Key
+	for _, path := range strings.Split(getEnvVariable("JWT_VERIFICATION_KEYS"), ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		key, err := auth.LoadKeyFile(path)
+		if err != nil {
+			log.Fatalf("Error loading JWT verification key: %s", err)
+		}
+		verification = append(verification, key)
+	}
+	keys, err := auth.NewKeySet(signing, verification...)
+	if err != nil {
+		log.Fatalf("Error loading JWT signing key: %s", err)
+	}
+	if secret != "" {
+		keys.AcceptHMAC(secret)
+	}
+	keys.SetPolicy(newJWTPolicy())
+	return keys
+}
+
+// newJWTPolicy overrides auth.DefaultTokenPolicy with JWT_ISSUER, JWT_AUDIENCE
+// and JWT_LEEWAY (a duration such as "30s") when they are set.
+func newJWTPolicy() auth.TokenPolicy {
+	policy := auth.DefaultTokenPolicy
+	if issuer := getEnvVariable("JWT_ISSUER"); issuer != "" {
+		policy.Issuer = issuer
+	}
+	if audience := getEnvVariable("JWT_AUDIENCE"); audience != "" {
+		policy.Audience = audience
+	}
+	if leeway := getEnvVariable("JWT_LEEWAY"); leeway != "" {
+		d, err := time.ParseDuration(leeway)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid JWT_LEEWAY %q", leeway)
+		}
+		policy.Leeway = d
+	}
+	return policy
+}
+
+// newTOTPSecretBox encrypts stored TOTP secrets with TOTP_ENCRYPTION_KEY, 32
+// hex encoded bytes. Changing the key makes enrolled authenticators unusable,
+// so it has to be kept like the database itself.
+func newTOTPSecretBox() *auth.SecretBox {
+	key, err := auth.ParseSecretBoxKey(getEnvVariable("TOTP_ENCRYPTION_KEY"))
+	if err != nil {
+		log.Fatalf("Invalid TOTP_ENCRYPTION_KEY: %s", err)
+	}
+	box, err := auth.NewSecretBox(key)
+	if err != nil {
+		log.Fatalf("Invalid TOTP_ENCRYPTION_KEY: %s", err)
+	}
+	return box
+}
+
+// newPasswordPolicy starts from auth.DefaultPasswordPolicy, adjusted by
+// PASSWORD_MIN_LENGTH and PASSWORD_MIN_ENTROPY. BREACHED_PASSWORDS_FILE
+// points at a sorted SHA-1 list of breached passwords to reject.
+func newPasswordPolicy() auth.PasswordPolicy {
+	policy := auth.DefaultPasswordPolicy
+	if minLength := getEnvVariable("PASSWORD_MIN_LENGTH"); minLength != "" {
+		n, err := strconv.Atoi(minLength)
+		if err != nil || n < 1 {
+			log.Fatalf("Invalid PASSWORD_MIN_LENGTH %q", minLength)
+		}
+		policy.MinLength = n
+	}
+	if minEntropy := getEnvVariable("PASSWORD_MIN_ENTROPY"); minEntropy != "" {
+		bits, err := strconv.ParseFloat(minEntropy, 64)
+		if err != nil || bits < 0 {
+			log.Fatalf("Invalid PASSWORD_MIN_ENTROPY %q", minEntropy)
+		}
+		policy.MinEntropy = bits
+	}
+	if path := getEnvVariable("BREACHED_PASSWORDS_FILE"); path != "" {
+		corpus, err := auth.OpenBreachCorpus(path)
+		if err != nil {
+			log.Fatalf("Error opening breached passwords file: %s", err)
+		}
+		policy.Breached = corpus
+	}
+	return policy
+}
+
 func getEnvVariable(key string) string {
 	err := godotenv.Load(".env")
 	if err != nil {
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/ZDSDD/Chirpy/internal/entities"
+	"github.com/google/uuid"
+)
+
+type mentionResponse struct {
+	UserID uuid.UUID `json:"user_id"`
+	Handle string    `json:"handle"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+}
+
+// syncChirpMentions replaces the stored mentions of a chirp with the
+// @handles in its current body that belong to a user. Pass queries bound to
+// the transaction that wrote the chirp.
+func syncChirpMentions(ctx context.Context, q *database.Queries, chirp *database.Chirp) error {
+	if err := q.DeleteChirpMentions(ctx, chirp.ID); err != nil {
+		return err
+	}
+	mentions := entities.ExtractMentions(chirp.Body)
+	if len(mentions) == 0 {
+		return nil
+	}
+	handles := make([]string, 0, len(mentions))
+	for _, mention := range mentions {
+		handles = append(handles, strings.ToLower(mention.Handle))
+	}
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return err
+	}
+	userIDs := make(map[string]uuid.UUID, len(users))
+	for _, user := range users {
+		userIDs[strings.ToLower(user.Handle)] = user.ID
+	}
+	for _, mention := range mentions {
+		userID, ok := userIDs[strings.ToLower(mention.Handle)]
+		if !ok {
+			continue
+		}
+		err := q.CreateChirpMention(ctx, database.CreateChirpMentionParams{
+			ChirpID:     chirp.ID,
+			UserID:      userID,
+			StartOffset: int32(mention.Start),
+			EndOffset:   int32(mention.End),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachMentions loads the resolved mentions of every chirp passed in with a
+// single query.
+func (cfg *apiConfig) attachMentions(ctx context.Context, chirps ...[]chirpResponse) error {
+	var chirpIDs []uuid.UUID
+	for _, list := range chirps {
+		for _, chirp := range list {
+			chirpIDs = append(chirpIDs, chirp.ID)
+		}
+	}
+	if len(chirpIDs) == 0 {
+		return nil
+	}
+	rows, err := cfg.db.GetMentionsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	byChirp := make(map[uuid.UUID][]mentionResponse)
+	for _, row := range rows {
+		byChirp[row.ChirpID] = append(byChirp[row.ChirpID], mentionResponse{
+			UserID: row.UserID,
+			Handle: row.Handle,
+			Start:  row.StartOffset,
+			End:    row.EndOffset,
+		})
+	}
+	for _, list := range chirps {
+		for i := range list {
+			list[i].Mentions = byChirp[list[i].ID]
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) handleGetMyMentions(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 400)
+		return
+	}
+	chirps, err := cfg.db.GetChirpsMentioningUser(r.Context(), database.GetChirpsMentioningUserParams{
+		UserID:          user.ID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	chirps, nextCursor := trimPage(chirps, page.Limit, chirpCursor)
+	chirpsResponse := mapChirpsToResponse(chirps)
+	if err := cfg.decorateChirps(r, chirpsResponse); err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	responseWithJson(pageResponse[chirpResponse]{
+		Data:       chirpsResponse,
+		NextCursor: nextCursor,
+	}, w, http.StatusOK)
+}
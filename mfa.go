@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/auth"
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	totpIssuer              = "Chirpy"
+	recoveryCodeCount       = 10
+	mfaChallengeTTL         = 5 * time.Minute
+	mfaChallengeMaxAttempts = 5
+)
+
+var errTOTPAlreadyConfirmed = errors.New("totp already confirmed")
+
+// handleEnrollTOTP starts setting up an authenticator app. 2FA isn't on
+// until handleConfirmTOTP sees a code from it; enrolling again before that
+// replaces the secret.
+func (cfg *apiConfig) handleEnrollTOTP(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	type enrollReqBody struct {
+		Password string `json:"password"`
+	}
+	var enrollReq enrollReqBody
+	json.NewDecoder(r.Body).Decode(&enrollReq)
+	if enrollReq.Password == "" {
+		responseWithJsonError(w, "Password is required to enable two-factor authentication", 400)
+		return
+	}
+	if err := auth.CheckPasswordHash(enrollReq.Password, user.HashedPassword); err != nil {
+		responseWithJsonError(w, "Password is incorrect", 403)
+		return
+	}
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	sealed, err := cfg.totpSecrets.Seal(secret, user.ID[:])
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	_, err = cfg.db.CreateTOTPCredential(r.Context(), database.CreateTOTPCredentialParams{
+		UserID: user.ID,
+		Secret: sealed,
+	})
+	// The upsert skips confirmed credentials, which returns no row.
+	if errors.Is(err, sql.ErrNoRows) {
+		responseWithJsonError(w, "Two-factor authentication is already enabled", 409)
+		return
+	}
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	responseWithJson(map[string]string{
+		"secret":      secret,
+		"otpauth_uri": auth.TOTPURI(secret, totpIssuer, user.Email),
+	}, w, http.StatusCreated)
+}
+
+// handleConfirmTOTP turns 2FA on once the user enters a code from the newly
+// enrolled app, and hands out the recovery codes. They are only ever shown
+// here.
+func (cfg *apiConfig) handleConfirmTOTP(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	type confirmReqBody struct {
+		Code string `json:"code"`
+	}
+	var confirmReq confirmReqBody
+	json.NewDecoder(r.Body).Decode(&confirmReq)
+	if confirmReq.Code == "" {
+		responseWithJsonError(w, "Code is required", 400)
+		return
+	}
+	cred, err := cfg.db.GetTOTPCredential(r.Context(), user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		responseWithJsonError(w, "Two-factor authentication enrollment not started", 404)
+		return
+	}
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	if cred.ConfirmedAt.Valid {
+		responseWithJsonError(w, "Two-factor authentication is already enabled", 409)
+		return
+	}
+	secret, err := cfg.totpSecret(cred)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	step, ok := auth.ValidateTOTP(secret, confirmReq.Code, time.Now())
+	if !ok {
+		responseWithJsonError(w, "Invalid code", 400)
+		return
+	}
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		n, err := q.ConfirmTOTPCredential(r.Context(), database.ConfirmTOTPCredentialParams{
+			UserID:       user.ID,
+			LastUsedStep: step,
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errTOTPAlreadyConfirmed
+		}
+		if err := q.DeleteRecoveryCodes(r.Context(), user.ID); err != nil {
+			return err
+		}
+		for _, code := range codes {
+			err := q.CreateRecoveryCode(r.Context(), database.CreateRecoveryCodeParams{
+				CodeHash: auth.HashToken(code),
+				UserID:   user.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errTOTPAlreadyConfirmed) {
+		responseWithJsonError(w, "Two-factor authentication is already enabled", 409)
+		return
+	}
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	responseWithJson(map[string][]string{"recovery_codes": codes}, w, http.StatusOK)
+}
+
+// handleDisableTOTP turns 2FA off. It takes the password and a current code
+// or recovery code, so a stolen access token alone can't do it.
+func (cfg *apiConfig) handleDisableTOTP(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	type disableReqBody struct {
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	var disableReq disableReqBody
+	json.NewDecoder(r.Body).Decode(&disableReq)
+	if disableReq.Password == "" {
+		responseWithJsonError(w, "Password is required to disable two-factor authentication", 400)
+		return
+	}
+	if err := auth.CheckPasswordHash(disableReq.Password, user.HashedPassword); err != nil {
+		responseWithJsonError(w, "Password is incorrect", 403)
+		return
+	}
+	cred, err := cfg.db.GetTOTPCredential(r.Context(), user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		responseWithJsonError(w, "Two-factor authentication is not enabled", 404)
+		return
+	}
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	// An unconfirmed enrollment never protected anything, so the password is
+	// enough to drop it.
+	if cred.ConfirmedAt.Valid {
+		ok, err := cfg.checkSecondFactor(r.Context(), cred, disableReq.Code, disableReq.RecoveryCode)
+		if err != nil {
+			responseWithJsonError(w, err.Error(), 500)
+			return
+		}
+		if !ok {
+			responseWithJsonError(w, "Invalid code", 403)
+			return
+		}
+	}
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		if err := q.DeleteTOTPCredential(r.Context(), user.ID); err != nil {
+			return err
+		}
+		return q.DeleteRecoveryCodes(r.Context(), user.ID)
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleLoginMFA finishes a login started by handleLogin for a user with 2FA,
+// trading the challenge token and a code or recovery code for real tokens.
+func (cfg *apiConfig) handleLoginMFA(w http.ResponseWriter, r *http.Request) {
+	type mfaReqBody struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	var mfaReq mfaReqBody
+	json.NewDecoder(r.Body).Decode(&mfaReq)
+	if mfaReq.MFAToken == "" {
+		responseWithJsonError(w, "MFA token is required", 400)
+		return
+	}
+	if mfaReq.Code == "" && mfaReq.RecoveryCode == "" {
+		responseWithJsonError(w, "Code or recovery code is required", 400)
+		return
+	}
+	tokenHash := auth.HashToken(mfaReq.MFAToken)
+	// Taking an attempt up front, in one statement, stops parallel requests
+	// from all guessing before any of them is counted.
+	challenge, err := cfg.db.RecordMFAChallengeAttempt(r.Context(), database.RecordMFAChallengeAttemptParams{
+		TokenHash:   tokenHash,
+		MaxAttempts: mfaChallengeMaxAttempts,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responseWithJsonError(w, "Invalid or expired MFA token", 401)
+		return
+	}
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	cred, err := cfg.db.GetTOTPCredential(r.Context(), challenge.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// 2FA was turned off since the challenge was issued.
+		responseWithJsonError(w, "Invalid or expired MFA token", 401)
+		return
+	}
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	// Challenges are cheap to get with the password, so wrong codes are also
+	// throttled across all of the user's challenges.
+	account, ip := "mfa:"+challenge.UserID.String(), clientIP(r)
+	if !cfg.allowLogin(w, account, ip) {
+		return
+	}
+	ok, err := cfg.checkSecondFactor(r.Context(), cred, mfaReq.Code, mfaReq.RecoveryCode)
+	if err != nil {
+		cfg.loginErrored(account, ip)
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	if !ok {
+		responseWithJsonError(w, "Invalid code", 401)
+		return
+	}
+	cfg.loginSucceeded(account, ip)
+	n, err := cfg.db.UseMFAChallenge(r.Context(), tokenHash)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	if n == 0 {
+		responseWithJsonError(w, "Invalid or expired MFA token", 401)
+		return
+	}
+	user, err := cfg.db.GetUserById(r.Context(), challenge.UserID)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	cfg.completeLogin(w, r, user)
+}
+
+// startMFAChallenge answers a correct password for a user with 2FA: instead
+// of tokens they get a short-lived challenge to present with a code.
+func (cfg *apiConfig) startMFAChallenge(w http.ResponseWriter, r *http.Request, user *database.User) {
+	token, tokenHash, err := auth.MakeRefreshToken()
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	challenge, err := cfg.db.CreateMFAChallenge(r.Context(), database.CreateMFAChallengeParams{
+		TokenHash: tokenHash,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(mfaChallengeTTL),
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	responseWithJson(struct {
+		MFARequired bool      `json:"mfa_required"`
+		MFAToken    string    `json:"mfa_token"`
+		ExpiresAt   time.Time `json:"expires_at"`
+	}{true, token, challenge.ExpiresAt}, w, http.StatusOK)
+}
+
+// hasTwoFactor reports whether the user has a confirmed authenticator.
+func (cfg *apiConfig) hasTwoFactor(ctx context.Context, userID uuid.UUID) (bool, error) {
+	cred, err := cfg.db.GetTOTPCredential(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return cred.ConfirmedAt.Valid, nil
+}
+
+// totpSecret decrypts the secret of cred, which is stored sealed to its user.
+func (cfg *apiConfig) totpSecret(cred database.TotpCredential) (string, error) {
+	return cfg.totpSecrets.Open(cred.Secret, cred.UserID[:])
+}
+
+// checkSecondFactor verifies a TOTP code or, if given instead, a recovery
+// code, using it up either way so it can't be presented again.
+func (cfg *apiConfig) checkSecondFactor(ctx context.Context, cred database.TotpCredential, code, recoveryCode string) (bool, error) {
+	if recoveryCode != "" {
+		n, err := cfg.db.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+			CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(recoveryCode)),
+			UserID:   cred.UserID,
+		})
+		return n == 1, err
+	}
+	secret, err := cfg.totpSecret(cred)
+	if err != nil {
+		return false, err
+	}
+	step, ok := auth.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	n, err := cfg.db.UseTOTPStep(ctx, database.UseTOTPStepParams{
+		UserID:       cred.UserID,
+		LastUsedStep: step,
+	})
+	return n == 1, err
+}
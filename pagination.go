@@ -0,0 +1,121 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageCursor points at the last row of a page. Rows are ordered by
+// (created_at, id) so the position stays stable while new rows are inserted.
+type pageCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+type pageRequest struct {
+	Limit  int32
+	Cursor *pageCursor
+}
+
+type pageResponse[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func parseSortOrder(r *http.Request) string {
+	// Default sorting order is "asc"
+	if strings.ToLower(r.URL.Query().Get("sort")) == "desc" {
+		return "desc"
+	}
+	return "asc"
+}
+
+func parsePageRequest(r *http.Request) (pageRequest, error) {
+	limit, err := parsePageLimit(r)
+	if err != nil {
+		return pageRequest{}, err
+	}
+	page := pageRequest{Limit: limit}
+	if raw := r.URL.Query().Get("cursor"); raw != "" {
+		var cursor pageCursor
+		if err := decodeCursor(raw, &cursor); err != nil {
+			return page, err
+		}
+		page.Cursor = &cursor
+	}
+	return page, nil
+}
+
+func parsePageLimit(r *http.Request) (int32, error) {
+	raw := r.URL.Query().Get("limit")
+	if raw == "" {
+		return defaultPageLimit, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	return int32(min(limit, maxPageLimit)), nil
+}
+
+// fetchLimit is the number of rows to query: one more than the page size so
+// we can tell whether another page exists.
+func (p pageRequest) fetchLimit() int32 {
+	return p.Limit + 1
+}
+
+func (p pageRequest) cursorCreatedAt() sql.NullTime {
+	if p.Cursor == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true}
+}
+
+func (p pageRequest) cursorID() uuid.NullUUID {
+	if p.Cursor == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+// trimPage drops the lookahead row and returns the cursor of the last row
+// kept, or an empty string when there is nothing left to fetch.
+func trimPage[T any](rows []T, limit int32, cursorOf func(T) any) ([]T, string) {
+	if len(rows) <= int(limit) {
+		return rows, ""
+	}
+	rows = rows[:limit]
+	return rows, encodeCursor(cursorOf(rows[len(rows)-1]))
+}
+
+func encodeCursor(v any) string {
+	dat, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(dat)
+}
+
+func decodeCursor(s string, v any) error {
+	dat, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return errors.New("invalid cursor")
+	}
+	if err := json.Unmarshal(dat, v); err != nil {
+		return errors.New("invalid cursor")
+	}
+	return nil
+}
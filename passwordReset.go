@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/auth"
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/ZDSDD/Chirpy/internal/mailer"
+)
+
+const (
+	passwordResetTTL = time.Hour
+	// passwordResetSendTimeout bounds the background work started by a
+	// forgot-password request.
+	passwordResetSendTimeout = 30 * time.Second
+)
+
+var (
+	// resetEmailPolicy stops one inbox from being flooded with reset mails.
+	// Every request counts, since none of them can be told apart as a
+	// mistake.
+	resetEmailPolicy = auth.LimiterPolicy{
+		FreeFailures: 3,
+		BaseDelay:    time.Minute,
+		LockoutAfter: 10,
+		Lockout:      time.Hour,
+		Window:       time.Hour,
+	}
+	// resetIPPolicy bounds the background sends one client can start.
+	resetIPPolicy = auth.LimiterPolicy{
+		FreeFailures: 10,
+		BaseDelay:    time.Second,
+		LockoutAfter: 50,
+		Lockout:      time.Hour,
+		Window:       time.Hour,
+	}
+)
+
+// passwordResetSends bounds how many forgot-password requests are being
+// worked on in the background at once, so a burst of them can't pile up
+// goroutines and mail.
+var passwordResetSends = make(chan struct{}, 16)
+
+// handleForgotPassword answers the same way whether or not the email belongs
+// to an account. The lookup and mail delivery happen after the response so
+// their timing doesn't give the answer away either. Requests are throttled
+// per email and per client address, which says nothing about the email
+// either.
+func (cfg *apiConfig) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	type forgotReqBody struct {
+		Email string `json:"email"`
+	}
+	var forgotReq forgotReqBody
+	json.NewDecoder(r.Body).Decode(&forgotReq)
+	if forgotReq.Email == "" {
+		responseWithJsonError(w, "Email is required", 400)
+		return
+	}
+	email, ip := strings.ToLower(forgotReq.Email), clientIP(r)
+	wait, ok := cfg.resetIPs.Allow(ip)
+	if ok {
+		if wait, ok = cfg.resetEmails.Allow(email); !ok {
+			cfg.resetIPs.Release(ip)
+		}
+	}
+	if !ok {
+		responseWithTooManyRequests(w, "Too many password reset requests, try again later", wait)
+		return
+	}
+	select {
+	case passwordResetSends <- struct{}{}:
+		go func() {
+			defer func() { <-passwordResetSends }()
+			ctx, cancel := context.WithTimeout(context.Background(), passwordResetSendTimeout)
+			defer cancel()
+			if err := cfg.sendPasswordReset(ctx, forgotReq.Email); err != nil {
+				log.Printf("Error sending password reset: %s", err)
+			}
+		}()
+	default:
+		// The response can't differ, so a dropped request only shows up in
+		// the log.
+		log.Printf("Too many password resets in flight, dropping one")
+	}
+	responseWithJson(map[string]string{
+		"message": "If an account uses that email, a password reset token has been sent to it",
+	}, w, http.StatusAccepted)
+}
+
+func (cfg *apiConfig) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := cfg.db.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	token, tokenHash, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+	err = cfg.withTx(ctx, func(q *database.Queries) error {
+		// Only the most recent token can be used.
+		if err := q.DeleteUnusedPasswordResetTokens(ctx, user.ID); err != nil {
+			return err
+		}
+		_, err := q.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+			TokenHash: tokenHash,
+			UserID:    user.ID,
+			ExpiresAt: time.Now().Add(passwordResetTTL),
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password for the Chirpy account @%s.\n\n"+
+			"Choose a new password by sending the token below to POST /api/password/reset:\n\n%s\n\n"+
+			"The token expires in %s. If this wasn't you, ignore this email.\n", user.Handle, token, passwordResetTTL),
+	})
+}
+
+// handleResetPassword sets a new password with a token from
+// handleForgotPassword and signs the user out everywhere.
+func (cfg *apiConfig) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	type resetReqBody struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	var resetReq resetReqBody
+	json.NewDecoder(r.Body).Decode(&resetReq)
+	if resetReq.Token == "" {
+		responseWithJsonError(w, "Token is required", 400)
+		return
+	}
+	if resetReq.Password == "" {
+		responseWithJsonError(w, "Password is required", 400)
+		return
+	}
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		reset, err := q.UsePasswordResetToken(r.Context(), auth.HashToken(resetReq.Token))
+		if err != nil {
+			return err
+		}
+		// Rejecting the password rolls back, so the token can be used again.
+		user, err := q.GetUserById(r.Context(), reset.UserID)
+		if err != nil {
+			return err
+		}
+		if err := cfg.passwordPolicy.Check(resetReq.Password, user.Email, user.Handle, user.DisplayName); err != nil {
+			return err
+		}
+		// Hashing is slow on purpose, so it waits until the token and the
+		// password are known to be good.
+		hashedPasswd, err := auth.HashPassword(resetReq.Password)
+		if err != nil {
+			return err
+		}
+		_, err = q.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+			HashedPassword: hashedPasswd,
+			ID:             reset.UserID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := revokeAccessTokens(r.Context(), q, reset.UserID); err != nil {
+			return err
+		}
+		// Whoever got into the account may have left a token behind.
+		if err := q.DeleteUserPersonalAccessTokens(r.Context(), reset.UserID); err != nil {
+			return err
+		}
+		return q.RevokeUserRefreshTokens(r.Context(), reset.UserID)
+	})
+	var policyErr *auth.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		responseWithJsonError(w, policyErr.Reason, 400)
+		return
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			responseWithJsonError(w, "Invalid or expired token", 400)
+			return
+		}
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/auth"
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Scopes a personal access token can be granted. Each route passes the one
+// it needs to requireValidJWTToken.
+const (
+	scopeChirpsRead   = "chirps:read"
+	scopeChirpsWrite  = "chirps:write"
+	scopeFollowsWrite = "follows:write"
+	// scopeSessionOnly marks routes that manage the account itself, which
+	// personal access tokens can't use whatever their scopes.
+	scopeSessionOnly = ""
+)
+
+var tokenScopes = []string{scopeChirpsRead, scopeChirpsWrite, scopeFollowsWrite}
+
+const maxTokenNameLength = 100
+
+type personalAccessTokenResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	// Token is only filled in on creation; afterwards only its digest exists.
+	Token string `json:"token,omitempty"`
+}
+
+func mapPersonalAccessToken(pat *database.PersonalAccessToken) personalAccessTokenResponse {
+	resp := personalAccessTokenResponse{
+		ID:        pat.ID,
+		Name:      pat.Name,
+		Scopes:    pat.Scopes,
+		CreatedAt: pat.CreatedAt,
+	}
+	if pat.ExpiresAt.Valid {
+		resp.ExpiresAt = &pat.ExpiresAt.Time
+	}
+	if pat.LastUsedAt.Valid {
+		resp.LastUsedAt = &pat.LastUsedAt.Time
+	}
+	return resp
+}
+
+func (cfg *apiConfig) handleCreatePersonalAccessToken(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	type createTokenReqBody struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	var createReq createTokenReqBody
+	if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+		responseWithJsonError(w, "Invalid request body", 400)
+		return
+	}
+	if createReq.Name == "" || len(createReq.Name) > maxTokenNameLength {
+		responseWithJsonError(w, fmt.Sprintf("Name must be 1-%d characters", maxTokenNameLength), 400)
+		return
+	}
+	if len(createReq.Scopes) == 0 {
+		responseWithJsonError(w, "At least one scope is required", 400)
+		return
+	}
+	for _, scope := range createReq.Scopes {
+		if !slices.Contains(tokenScopes, scope) {
+			responseWithJsonError(w, fmt.Sprintf("Unknown scope %q", scope), 400)
+			return
+		}
+	}
+	slices.Sort(createReq.Scopes)
+	scopes := slices.Compact(createReq.Scopes)
+	var expiresAt sql.NullTime
+	if createReq.ExpiresAt != nil {
+		if !createReq.ExpiresAt.After(time.Now()) {
+			responseWithJsonError(w, "Expiry must be in the future", 400)
+			return
+		}
+		expiresAt = sql.NullTime{Time: *createReq.ExpiresAt, Valid: true}
+	}
+
+	token, tokenHash, err := auth.MakePersonalAccessToken()
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	pat, err := cfg.db.CreatePersonalAccessToken(r.Context(), database.CreatePersonalAccessTokenParams{
+		UserID:    user.ID,
+		Name:      createReq.Name,
+		TokenHash: tokenHash,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	resp := mapPersonalAccessToken(&pat)
+	resp.Token = token
+	responseWithJson(resp, w, http.StatusCreated)
+}
+
+func (cfg *apiConfig) handleGetPersonalAccessTokens(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	pats, err := cfg.db.GetUserPersonalAccessTokens(r.Context(), user.ID)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	tokens := make([]personalAccessTokenResponse, 0, len(pats))
+	for i := range pats {
+		tokens = append(tokens, mapPersonalAccessToken(&pats[i]))
+	}
+	responseWithJson(tokens, w, http.StatusOK)
+}
+
+func (cfg *apiConfig) handleDeletePersonalAccessToken(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	tokenID, err := uuid.Parse(r.PathValue("tokenID"))
+	if err != nil {
+		responseWithJsonError(w, "Invalid token ID", 400)
+		return
+	}
+	n, err := cfg.db.DeletePersonalAccessToken(r.Context(), database.DeletePersonalAccessTokenParams{
+		ID:     tokenID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	if n == 0 {
+		responseWithJsonError(w, "Token not found", 404)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authenticatePersonalAccessToken is authenticateToken for personal access
+// tokens, which are turned down unless they are valid and were granted scope.
+func (cfg *apiConfig) authenticatePersonalAccessToken(ctx context.Context, token, scope string) (*database.User, error) {
+	pat, err := cfg.db.GetPersonalAccessToken(ctx, auth.HashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &authError{http.StatusUnauthorized, "Invalid or expired personal access token"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if scope == scopeSessionOnly {
+		return nil, &authError{http.StatusForbidden, "Personal access tokens can't be used here, log in instead"}
+	}
+	if !slices.Contains(pat.Scopes, scope) {
+		return nil, &authError{http.StatusForbidden, fmt.Sprintf("Token is missing the %s scope", scope)}
+	}
+	user, err := cfg.db.GetUserById(ctx, pat.UserID)
+	if err != nil {
+		return nil, &authError{http.StatusUnauthorized, "User not found"}
+	}
+	if user.DeletedAt.Valid {
+		return nil, &authError{http.StatusUnauthorized, "Account is scheduled for deletion, log in again to restore it"}
+	}
+	if err := cfg.db.TouchPersonalAccessToken(ctx, pat.ID); err != nil {
+		log.Printf("Error recording personal access token use: %s", err)
+	}
+	return &user, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/ZDSDD/Chirpy/internal/entities"
+	"github.com/google/uuid"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxLocationLength    = 30
+)
+
+// profileUpdate holds the public profile fields of a user update request.
+// Nil fields are left unchanged.
+type profileUpdate struct {
+	Handle      *string `json:"handle"`
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	Location    *string `json:"location"`
+}
+
+func (p profileUpdate) isEmpty() bool {
+	return p.Handle == nil && p.DisplayName == nil && p.Bio == nil && p.Location == nil
+}
+
+// validateHandle checks a handle a user picked, on sign-up or later.
+func validateHandle(handle string) error {
+	if !entities.HandlePattern.MatchString(handle) {
+		return errors.New("Handle must be 1-30 letters, digits or underscores")
+	}
+	if entities.IsReservedHandle(handle) {
+		return fmt.Errorf("Handle %q is reserved", handle)
+	}
+	return nil
+}
+
+func (p profileUpdate) validate() error {
+	if p.Handle != nil {
+		if err := validateHandle(*p.Handle); err != nil {
+			return err
+		}
+	}
+	if p.DisplayName != nil && utf8.RuneCountInString(*p.DisplayName) > maxDisplayNameLength {
+		return errors.New("Display name is too long")
+	}
+	if p.Bio != nil && utf8.RuneCountInString(*p.Bio) > maxBioLength {
+		return errors.New("Bio is too long")
+	}
+	if p.Location != nil && utf8.RuneCountInString(*p.Location) > maxLocationLength {
+		return errors.New("Location is too long")
+	}
+	return nil
+}
+
+func (p profileUpdate) params(userID uuid.UUID) database.UpdateUserProfileParams {
+	return database.UpdateUserProfileParams{
+		Handle:      toNullString(p.Handle),
+		DisplayName: toNullString(p.DisplayName),
+		Bio:         toNullString(p.Bio),
+		Location:    toNullString(p.Location),
+		ID:          userID,
+	}
+}
+
+func toNullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+type publicProfileResponse struct {
+	ID             uuid.UUID `json:"id"`
+	Handle         string    `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	Location       string    `json:"location"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	CreatedAt      time.Time `json:"created_at"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	ChirpCount     int64     `json:"chirp_count"`
+}
+
+// handleGetUserProfile returns the public profile of a user looked up by ID
+// or by handle. It never includes the email address.
+func (cfg *apiConfig) handleGetUserProfile(w http.ResponseWriter, r *http.Request) {
+	handleOrID := r.PathValue("handleOrID")
+	var user database.User
+	var err error
+	if userID, parseErr := uuid.Parse(handleOrID); parseErr == nil {
+		user, err = cfg.db.GetUserById(r.Context(), userID)
+	} else {
+		user, err = cfg.db.GetUserByHandle(r.Context(), handleOrID)
+	}
+	if err == nil && user.DeletedAt.Valid {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			responseWithJsonError(w, "User not found", 404)
+		} else {
+			responseWithJsonError(w, err.Error(), 500)
+		}
+		return
+	}
+	counts, err := cfg.db.GetUserProfileCounts(r.Context(), user.ID)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	responseWithJson(publicProfileResponse{
+		ID:             user.ID,
+		Handle:         user.Handle,
+		DisplayName:    user.DisplayName,
+		Bio:            user.Bio,
+		Location:       user.Location,
+		IsChirpyRed:    user.IsChirpyRed,
+		CreatedAt:      user.CreatedAt,
+		FollowerCount:  counts.FollowerCount,
+		FollowingCount: counts.FollowingCount,
+		ChirpCount:     counts.ChirpCount,
+	}, w, http.StatusOK)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// embeddedChirpResponse is the original chirp shown inside a rechirp or a
+// quote. Once the original is deleted only Deleted is set, as a tombstone.
+type embeddedChirpResponse struct {
+	*chirpResponse
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+func (cfg *apiConfig) createRechirp(w http.ResponseWriter, r *http.Request, user *database.User, originalID uuid.UUID) {
+	original, err := cfg.resolveOriginal(r.Context(), originalID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			responseWithJsonError(w, "Chirp being rechirped not found", 404)
+		} else {
+			responseWithJsonError(w, err.Error(), 500)
+		}
+		return
+	}
+	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
+		UserID:    user.ID,
+		RechirpOf: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if err != nil {
+		if isUniqueViolation(err, "idx_chirps_user_id_rechirp_of") {
+			responseWithJsonError(w, "Chirp already rechirped", 409)
+			return
+		}
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	cfg.respondWithCreatedChirp(w, r, &chirp)
+}
+
+// resolveOriginal looks up a chirp being rechirped or quoted. A rechirp has
+// no content of its own, so amplifying one amplifies its original instead.
+func (cfg *apiConfig) resolveOriginal(ctx context.Context, chirpID uuid.UUID) (database.Chirp, error) {
+	chirp, err := cfg.db.GetChirp(ctx, chirpID)
+	if err != nil {
+		return chirp, err
+	}
+	if chirp.RechirpOf.Valid {
+		return cfg.db.GetChirp(ctx, chirp.RechirpOf.UUID)
+	}
+	return chirp, nil
+}
+
+// attachOriginals embeds the originals of every rechirp and quote passed in,
+// loading them with a single query.
+func (cfg *apiConfig) attachOriginals(ctx context.Context, chirps ...[]chirpResponse) error {
+	var originalIDs []uuid.UUID
+	for _, list := range chirps {
+		for _, chirp := range list {
+			if chirp.rechirpOfID.Valid {
+				originalIDs = append(originalIDs, chirp.rechirpOfID.UUID)
+			}
+			if chirp.quoteOfID.Valid {
+				originalIDs = append(originalIDs, chirp.quoteOfID.UUID)
+			}
+		}
+	}
+	if len(originalIDs) == 0 {
+		return nil
+	}
+	originals, err := cfg.db.GetChirpsByIDs(ctx, originalIDs)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uuid.UUID]*chirpResponse, len(originals))
+	for _, original := range originals {
+		resp := mapChirpToResponse(&original)
+		byID[original.ID] = &resp
+	}
+	embed := func(id uuid.UUID) *embeddedChirpResponse {
+		if original, ok := byID[id]; ok {
+			return &embeddedChirpResponse{chirpResponse: original}
+		}
+		// Deleted between loading the chirp and loading its original.
+		return &embeddedChirpResponse{Deleted: true}
+	}
+	for _, list := range chirps {
+		for i := range list {
+			if list[i].rechirpOfID.Valid {
+				list[i].RechirpOf = embed(list[i].rechirpOfID.UUID)
+			}
+			if list[i].quoteOfID.Valid {
+				list[i].QuoteOf = embed(list[i].quoteOfID.UUID)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/auth"
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// A session is one refresh token family: it starts at login and survives
+// every rotation, so its ID is the family ID.
+type sessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (cfg *apiConfig) handleGetSessions(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	rows, err := cfg.db.GetUserSessions(r.Context(), user.ID)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	sessions := make([]sessionResponse, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, sessionResponse{
+			ID:         row.FamilyID,
+			CreatedAt:  row.CreatedAt,
+			LastUsedAt: row.LastUsedAt,
+			UserAgent:  row.UserAgent,
+			IP:         row.Ip,
+			ExpiresAt:  row.ExpiresAt,
+		})
+	}
+	responseWithJson(sessions, w, http.StatusOK)
+}
+
+func (cfg *apiConfig) handleRevokeSession(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	sessionID, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		responseWithJsonError(w, "Invalid session ID", 400)
+		return
+	}
+	n, err := cfg.db.RevokeUserSession(r.Context(), database.RevokeUserSessionParams{
+		UserID:   user.ID,
+		FamilyID: sessionID,
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	if n == 0 {
+		responseWithJsonError(w, "Session not found", 404)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRevokeOtherSessions signs the user out everywhere except the session
+// holding the refresh token in the request body. Access tokens carry no
+// session, so the refresh token is what identifies the current one.
+func (cfg *apiConfig) handleRevokeOtherSessions(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	type revokeReqBody struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	var revokeReq revokeReqBody
+	json.NewDecoder(r.Body).Decode(&revokeReq)
+	if revokeReq.RefreshToken == "" {
+		responseWithJsonError(w, "Refresh token is required", 400)
+		return
+	}
+	rtdb, err := cfg.db.GetRefreshToken(r.Context(), auth.HashToken(revokeReq.RefreshToken))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	if err != nil || rtdb.UserID != user.ID || rtdb.RevokedAt.Valid || rtdb.ReplacedBy.Valid {
+		responseWithJsonError(w, "Refresh token is not an active session", 400)
+		return
+	}
+	err = cfg.db.RevokeOtherUserSessions(r.Context(), database.RevokeOtherUserSessionsParams{
+		UserID:   user.ID,
+		FamilyID: rtdb.FamilyID,
+	})
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clientIP is the address of the peer that sent r. Forwarding headers are
+// ignored because any client can set them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
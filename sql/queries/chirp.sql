@@ -1,32 +1,67 @@
 -- name: CreateChirp :one
 INSERT INTO
-    chirps (id, created_at, updated_at, user_id, body)
+    chirps (
+        id,
+        created_at,
+        updated_at,
+        user_id,
+        body,
+        in_reply_to,
+        rechirp_of,
+        quote_of,
+        is_quote
+    )
 VALUES
-    (gen_random_uuid(), NOW(), NOW(), $1, $2) RETURNING *;
+    (
+        gen_random_uuid(),
+        NOW(),
+        NOW(),
+        $1,
+        $2,
+        $3,
+        $4,
+        $5,
+        $6
+    ) RETURNING *;
 
--- name: GetChirps :many
+-- name: GetChirp :one
 SELECT
     chirps.*
 FROM
     chirps
-ORDER BY
-    CASE
-        WHEN $1 = 'asc' THEN created_at
-        ELSE NULL
-    END ASC,
-    CASE
-        WHEN $1 = 'desc' THEN created_at
-        ELSE NULL
-    END DESC;
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    chirps.id = $1
+    AND users.deleted_at IS NULL;
 
+-- name: GetChirpsByIDs :many
+SELECT
+    chirps.*
+FROM
+    chirps
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND chirps.id = ANY(@ids::uuid[]);
 
--- name: GetChirp :one
+-- name: GetChirpForUpdate :one
 SELECT
     chirps.*
 FROM
     chirps
 WHERE
-    id = $1;
+    id = $1 FOR UPDATE;
+
+-- name: UpdateChirpBody :one
+UPDATE
+    chirps
+SET
+    body = $1,
+    edited_at = NOW(),
+    updated_at = NOW()
+WHERE
+    id = $2
+RETURNING *;
 
 -- name: DeleteChirp :exec
 DELETE FROM
@@ -34,16 +69,128 @@ DELETE FROM
 WHERE
     id = $1;
 
--- name: GetChirpsByUser :many
-SELECT chirps.*
-FROM chirps
-WHERE user_id = $1
+-- name: GetChirpsPageAsc :many
+SELECT
+    chirps.*
+FROM
+    chirps
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND (sqlc.narg(author_id)::uuid IS NULL OR chirps.user_id = sqlc.narg(author_id))
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid)
+    )
+ORDER BY
+    chirps.created_at ASC,
+    chirps.id ASC
+LIMIT
+    @page_limit;
+
+-- name: GetChirpsPageDesc :many
+SELECT
+    chirps.*
+FROM
+    chirps
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND (sqlc.narg(author_id)::uuid IS NULL OR chirps.user_id = sqlc.narg(author_id))
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid)
+    )
+ORDER BY
+    chirps.created_at DESC,
+    chirps.id DESC
+LIMIT
+    @page_limit;
+
+-- name: SearchChirps :many
+SELECT
+    sqlc.embed(chirps),
+    ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', @query::text)) AS rank
+FROM
+    chirps
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND to_tsvector('english', chirps.body) @@ websearch_to_tsquery('english', @query::text)
+    AND (sqlc.narg(author_id)::uuid IS NULL OR chirps.user_id = sqlc.narg(author_id))
+    AND (sqlc.narg(created_after)::timestamp IS NULL OR chirps.created_at >= sqlc.narg(created_after))
+    AND (sqlc.narg(created_before)::timestamp IS NULL OR chirps.created_at < sqlc.narg(created_before))
+    AND (
+        sqlc.narg(cursor_rank)::real IS NULL
+        OR (ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', @query::text)), chirps.id) < (sqlc.narg(cursor_rank), sqlc.narg(cursor_id)::uuid)
+    )
+ORDER BY
+    rank DESC,
+    chirps.id DESC
+LIMIT
+    @page_limit;
+
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT
+        chirps.id,
+        chirps.in_reply_to,
+        0 AS depth
+    FROM
+        chirps
+    WHERE
+        chirps.id = @chirp_id::uuid
+    UNION ALL
+    SELECT
+        parent.id,
+        parent.in_reply_to,
+        ancestors.depth + 1
+    FROM
+        chirps parent
+        JOIN ancestors ON parent.id = ancestors.in_reply_to
+)
+SELECT
+    chirps.*
+FROM
+    chirps
+    JOIN ancestors ON chirps.id = ancestors.id
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    ancestors.depth > 0
+    AND users.deleted_at IS NULL
+ORDER BY
+    ancestors.depth DESC;
+
+-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT
+        chirps.id
+    FROM
+        chirps
+    WHERE
+        chirps.in_reply_to = @chirp_id::uuid
+    UNION ALL
+    SELECT
+        reply.id
+    FROM
+        chirps reply
+        JOIN descendants ON reply.in_reply_to = descendants.id
+)
+SELECT
+    chirps.*
+FROM
+    chirps
+    JOIN descendants ON chirps.id = descendants.id
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid)
+    )
 ORDER BY
-    CASE
-        WHEN $2 = 'asc' THEN created_at
-        ELSE NULL
-    END ASC,
-    CASE
-        WHEN $2 = 'desc' THEN created_at
-        ELSE NULL
-    END DESC;
\ No newline at end of file
+    chirps.created_at ASC,
+    chirps.id ASC
+LIMIT
+    @page_limit;
\ No newline at end of file
@@ -0,0 +1,41 @@
+-- name: CreateChirpLike :exec
+INSERT INTO
+    chirp_likes (chirp_id, user_id, created_at)
+VALUES
+    ($1, $2, NOW()) ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpLike :exec
+DELETE FROM
+    chirp_likes
+WHERE
+    chirp_id = $1
+    AND user_id = $2;
+
+-- name: GetChirpLikes :many
+SELECT
+    chirp_likes.user_id,
+    chirp_likes.created_at AS liked_at
+FROM
+    chirp_likes
+    JOIN users ON users.id = chirp_likes.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND chirp_likes.chirp_id = @chirp_id
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (chirp_likes.created_at, chirp_likes.user_id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid)
+    )
+ORDER BY
+    chirp_likes.created_at DESC,
+    chirp_likes.user_id DESC
+LIMIT
+    @page_limit;
+
+-- name: GetLikedChirpIDs :many
+SELECT
+    chirp_id
+FROM
+    chirp_likes
+WHERE
+    user_id = @user_id
+    AND chirp_id = ANY(@chirp_ids::uuid[]);
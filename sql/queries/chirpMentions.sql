@@ -0,0 +1,54 @@
+-- name: CreateChirpMention :exec
+INSERT INTO
+    chirp_mentions (chirp_id, user_id, start_offset, end_offset, created_at)
+VALUES
+    ($1, $2, $3, $4, NOW());
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM
+    chirp_mentions
+WHERE
+    chirp_id = $1;
+
+-- name: GetMentionsForChirps :many
+SELECT
+    chirp_mentions.chirp_id,
+    chirp_mentions.user_id,
+    users.handle,
+    chirp_mentions.start_offset,
+    chirp_mentions.end_offset
+FROM
+    chirp_mentions
+    JOIN users ON users.id = chirp_mentions.user_id
+WHERE
+    chirp_mentions.chirp_id = ANY(@chirp_ids::uuid[])
+ORDER BY
+    chirp_mentions.chirp_id,
+    chirp_mentions.start_offset;
+
+-- name: GetChirpsMentioningUser :many
+SELECT
+    chirps.*
+FROM
+    chirps
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND EXISTS (
+        SELECT
+            1
+        FROM
+            chirp_mentions
+        WHERE
+            chirp_mentions.chirp_id = chirps.id
+            AND chirp_mentions.user_id = @user_id
+    )
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid)
+    )
+ORDER BY
+    chirps.created_at DESC,
+    chirps.id DESC
+LIMIT
+    @page_limit;
@@ -0,0 +1,16 @@
+-- name: CreateChirpRevision :one
+INSERT INTO
+    chirp_revisions (id, chirp_id, body, created_at)
+VALUES
+    (gen_random_uuid(), $1, $2, NOW()) RETURNING *;
+
+-- name: GetChirpRevisions :many
+SELECT
+    chirp_revisions.*
+FROM
+    chirp_revisions
+WHERE
+    chirp_id = $1
+ORDER BY
+    created_at DESC,
+    id DESC;
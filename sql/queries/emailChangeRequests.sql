@@ -0,0 +1,29 @@
+-- name: CreateEmailChangeRequest :one
+INSERT INTO
+    email_change_requests (
+        token_hash,
+        user_id,
+        new_email,
+        created_at,
+        expires_at
+    )
+VALUES
+    ($1, $2, $3, NOW(), $4) RETURNING *;
+
+-- name: DeletePendingEmailChangeRequests :exec
+DELETE FROM
+    email_change_requests
+WHERE
+    user_id = $1
+    AND confirmed_at IS NULL;
+
+-- name: ConfirmEmailChangeRequest :one
+UPDATE
+    email_change_requests
+SET
+    confirmed_at = NOW()
+WHERE
+    token_hash = $1
+    AND confirmed_at IS NULL
+    AND expires_at > NOW()
+RETURNING *;
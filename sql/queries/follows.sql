@@ -0,0 +1,104 @@
+-- name: CreateFollow :exec
+INSERT INTO
+    follows (follower_id, followee_id, created_at)
+VALUES
+    ($1, $2, NOW()) ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollow :exec
+DELETE FROM
+    follows
+WHERE
+    follower_id = $1
+    AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT
+    follows.follower_id AS user_id,
+    follows.created_at AS followed_at
+FROM
+    follows
+    JOIN users ON users.id = follows.follower_id
+WHERE
+    users.deleted_at IS NULL
+    AND follows.followee_id = @user_id
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (follows.created_at, follows.follower_id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid)
+    )
+ORDER BY
+    follows.created_at DESC,
+    follows.follower_id DESC
+LIMIT
+    @page_limit;
+
+-- name: GetFollowing :many
+SELECT
+    follows.followee_id AS user_id,
+    follows.created_at AS followed_at
+FROM
+    follows
+    JOIN users ON users.id = follows.followee_id
+WHERE
+    users.deleted_at IS NULL
+    AND follows.follower_id = @user_id
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (follows.created_at, follows.followee_id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid)
+    )
+ORDER BY
+    follows.created_at DESC,
+    follows.followee_id DESC
+LIMIT
+    @page_limit;
+
+-- name: GetTimelinePageAsc :many
+SELECT
+    chirps.*
+FROM
+    chirps
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND chirps.user_id IN (
+        SELECT
+            followee_id
+        FROM
+            follows
+        WHERE
+            follower_id = @user_id
+    )
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid)
+    )
+ORDER BY
+    chirps.created_at ASC,
+    chirps.id ASC
+LIMIT
+    @page_limit;
+
+-- name: GetTimelinePageDesc :many
+SELECT
+    chirps.*
+FROM
+    chirps
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND chirps.user_id IN (
+        SELECT
+            followee_id
+        FROM
+            follows
+        WHERE
+            follower_id = @user_id
+    )
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid)
+    )
+ORDER BY
+    chirps.created_at DESC,
+    chirps.id DESC
+LIMIT
+    @page_limit;
@@ -0,0 +1,61 @@
+-- name: UpsertHashtag :one
+INSERT INTO
+    hashtags (id, tag, created_at)
+VALUES
+    (gen_random_uuid(), $1, NOW()) ON CONFLICT (tag) DO
+UPDATE
+SET
+    tag = EXCLUDED.tag RETURNING *;
+
+-- name: CreateChirpHashtag :exec
+INSERT INTO
+    chirp_hashtags (chirp_id, hashtag_id, created_at)
+VALUES
+    ($1, $2, $3) ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM
+    chirp_hashtags
+WHERE
+    chirp_id = $1;
+
+-- name: GetChirpsByHashtag :many
+SELECT
+    chirps.*
+FROM
+    chirps
+    JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+    JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND hashtags.tag = @tag
+    AND (
+        sqlc.narg(cursor_created_at)::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid)
+    )
+ORDER BY
+    chirps.created_at DESC,
+    chirps.id DESC
+LIMIT
+    @page_limit;
+
+-- name: GetTrendingHashtags :many
+SELECT
+    hashtags.tag,
+    COUNT(*) AS uses
+FROM
+    chirp_hashtags
+    JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+    JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+    JOIN users ON users.id = chirps.user_id
+WHERE
+    users.deleted_at IS NULL
+    AND chirp_hashtags.created_at >= @since
+GROUP BY
+    hashtags.tag
+ORDER BY
+    uses DESC,
+    hashtags.tag ASC
+LIMIT
+    @page_limit;
@@ -0,0 +1,31 @@
+-- name: CreateMFAChallenge :one
+INSERT INTO
+    mfa_challenges (token_hash, user_id, created_at, expires_at)
+VALUES
+    ($1, $2, NOW(), $3) RETURNING *;
+
+-- name: DeleteExpiredMFAChallenges :execrows
+DELETE FROM
+    mfa_challenges
+WHERE
+    expires_at <= NOW();
+
+-- name: RecordMFAChallengeAttempt :one
+UPDATE
+    mfa_challenges
+SET
+    attempts = attempts + 1
+WHERE
+    token_hash = @token_hash
+    AND attempts < @max_attempts
+    AND used_at IS NULL
+    AND expires_at > NOW() RETURNING *;
+
+-- name: UseMFAChallenge :execrows
+UPDATE
+    mfa_challenges
+SET
+    used_at = NOW()
+WHERE
+    token_hash = $1
+    AND used_at IS NULL;
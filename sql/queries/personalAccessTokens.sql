@@ -0,0 +1,64 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO
+    personal_access_tokens (
+        id,
+        user_id,
+        name,
+        token_hash,
+        scopes,
+        created_at,
+        expires_at
+    )
+VALUES
+    (
+        gen_random_uuid(),
+        $1,
+        $2,
+        $3,
+        $4,
+        NOW(),
+        $5
+    ) RETURNING *;
+
+-- name: DeletePersonalAccessToken :execrows
+DELETE FROM
+    personal_access_tokens
+WHERE
+    id = $1
+    AND user_id = $2;
+
+-- name: DeleteUserPersonalAccessTokens :exec
+DELETE FROM
+    personal_access_tokens
+WHERE
+    user_id = $1;
+
+-- name: GetPersonalAccessToken :one
+SELECT
+    *
+FROM
+    personal_access_tokens
+WHERE
+    token_hash = $1
+    AND (
+        expires_at IS NULL
+        OR expires_at > NOW()
+    );
+
+-- name: GetUserPersonalAccessTokens :many
+SELECT
+    *
+FROM
+    personal_access_tokens
+WHERE
+    user_id = $1
+ORDER BY
+    created_at DESC;
+
+-- name: TouchPersonalAccessToken :exec
+UPDATE
+    personal_access_tokens
+SET
+    last_used_at = NOW()
+WHERE
+    id = $1;
@@ -4,19 +4,23 @@ SELECT
 FROM
     refresh_tokens
 WHERE
-    token = $1;
+    token_hash = $1;
 
 -- name: CreateRefreshToken :one
 INSERT INTO
     refresh_tokens (
-        token,
+        token_hash,
         created_at,
         updated_at,
         user_id,
-        expires_at
+        expires_at,
+        family_id,
+        last_used_at,
+        user_agent,
+        ip
     )
 VALUES
-    ($1, NOW(), NOW(), $2, $3) RETURNING *;
+    ($1, NOW(), NOW(), $2, $3, $4, NOW(), $5, $6) RETURNING *;
 
 -- name: RevokeRefreshToken :exec
 UPDATE
@@ -25,8 +29,93 @@ SET
     revoked_at = NOW(),
     updated_at = NOW()
 WHERE
-    token = $1;
+    token_hash = $1;
 
 -- name: PurgeRefreshTokens :exec
 DELETE FROM
-    refresh_tokens;
\ No newline at end of file
+    refresh_tokens;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE
+    refresh_tokens
+SET
+    revoked_at = NOW(),
+    updated_at = NOW()
+WHERE
+    user_id = $1
+    AND revoked_at IS NULL;
+
+-- name: GetRefreshTokensByUser :many
+SELECT
+    *
+FROM
+    refresh_tokens
+WHERE
+    user_id = $1
+ORDER BY
+    created_at ASC;
+
+-- name: RotateRefreshToken :one
+UPDATE
+    refresh_tokens
+SET
+    replaced_by = @replaced_by,
+    updated_at = NOW()
+WHERE
+    token_hash = @token_hash
+    AND replaced_by IS NULL
+    AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE
+    refresh_tokens
+SET
+    revoked_at = NOW(),
+    updated_at = NOW()
+WHERE
+    family_id = $1
+    AND revoked_at IS NULL;
+
+-- name: GetUserSessions :many
+SELECT
+    live.family_id,
+    MIN(family.created_at)::timestamp AS created_at,
+    live.last_used_at,
+    live.user_agent,
+    live.ip,
+    live.expires_at
+FROM
+    refresh_tokens live
+    JOIN refresh_tokens family ON family.family_id = live.family_id
+WHERE
+    live.user_id = $1
+    AND live.revoked_at IS NULL
+    AND live.replaced_by IS NULL
+    AND live.expires_at > NOW()
+GROUP BY
+    live.token_hash
+ORDER BY
+    live.last_used_at DESC;
+
+-- name: RevokeUserSession :execrows
+UPDATE
+    refresh_tokens
+SET
+    revoked_at = NOW(),
+    updated_at = NOW()
+WHERE
+    user_id = @user_id
+    AND family_id = @family_id
+    AND revoked_at IS NULL;
+
+-- name: RevokeOtherUserSessions :exec
+UPDATE
+    refresh_tokens
+SET
+    revoked_at = NOW(),
+    updated_at = NOW()
+WHERE
+    user_id = @user_id
+    AND family_id <> @family_id
+    AND revoked_at IS NULL;
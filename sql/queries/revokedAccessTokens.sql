@@ -0,0 +1,20 @@
+-- name: RevokeAccessToken :exec
+INSERT INTO
+    revoked_access_tokens (jti, expires_at)
+VALUES
+    ($1, $2) ON CONFLICT (jti) DO NOTHING;
+
+-- name: GetRevokedAccessToken :one
+SELECT
+    *
+FROM
+    revoked_access_tokens
+WHERE
+    jti = $1
+    AND expires_at > NOW();
+
+-- name: DeleteExpiredRevokedAccessTokens :execrows
+DELETE FROM
+    revoked_access_tokens
+WHERE
+    expires_at <= NOW();
@@ -0,0 +1,45 @@
+-- name: ConfirmTOTPCredential :execrows
+UPDATE
+    totp_credentials
+SET
+    confirmed_at = NOW(),
+    last_used_step = $2
+WHERE
+    user_id = $1
+    AND confirmed_at IS NULL;
+
+-- name: CreateTOTPCredential :one
+INSERT INTO
+    totp_credentials (user_id, secret, created_at)
+VALUES
+    ($1, $2, NOW()) ON CONFLICT (user_id) DO
+UPDATE
+SET
+    secret = EXCLUDED.secret,
+    created_at = NOW(),
+    last_used_step = 0
+WHERE
+    totp_credentials.confirmed_at IS NULL RETURNING *;
+
+-- name: DeleteTOTPCredential :exec
+DELETE FROM
+    totp_credentials
+WHERE
+    user_id = $1;
+
+-- name: GetTOTPCredential :one
+SELECT
+    *
+FROM
+    totp_credentials
+WHERE
+    user_id = $1;
+
+-- name: UseTOTPStep :execrows
+UPDATE
+    totp_credentials
+SET
+    last_used_step = $2
+WHERE
+    user_id = $1
+    AND last_used_step < $2;
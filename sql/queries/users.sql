@@ -5,7 +5,8 @@ INSERT INTO
         created_at,
         updated_at,
         email,
-        hashed_password
+        hashed_password,
+        handle
     )
 VALUES
     (
@@ -13,7 +14,8 @@ VALUES
         NOW(),
         NOW(),
         $1,
-        $2
+        $2,
+        $3
     ) RETURNING *;
 
 -- name: PurgeUsers :exec
@@ -36,23 +38,155 @@ FROM
 WHERE
     email = $1;
 
--- name: UpdateUser :one
+-- name: UpdateIsChirpyRed :one
 UPDATE
     users
 SET
-    email = $1,
-    hashed_password = $2,
+    is_chirpy_red = $1,
     updated_at = NOW()
 WHERE
-    id = $3
+    id = $2
 RETURNING *;
 
--- name: UpdateIsChirpyRed :one
+-- name: GetUsersByHandles :many
+SELECT
+    id,
+    handle
+FROM
+    users
+WHERE
+    lower(handle) = ANY(@handles::text[])
+    AND deleted_at IS NULL;
+
+-- name: GetUserByHandle :one
+SELECT
+    users.*
+FROM
+    users
+WHERE
+    lower(handle) = lower(@handle);
+
+-- name: UpdateUserProfile :one
 UPDATE
     users
 SET
-    is_chirpy_red = $1,
+    handle = COALESCE(sqlc.narg(handle), handle),
+    display_name = COALESCE(sqlc.narg(display_name), display_name),
+    bio = COALESCE(sqlc.narg(bio), bio),
+    location = COALESCE(sqlc.narg(location), location),
+    updated_at = NOW()
+WHERE
+    id = @id
+RETURNING *;
+
+-- name: GetUserProfileCounts :one
+SELECT
+    (
+        SELECT
+            COUNT(*)
+        FROM
+            follows
+            JOIN users ON users.id = follows.follower_id
+        WHERE
+            users.deleted_at IS NULL
+            AND follows.followee_id = @user_id
+    ) AS follower_count,
+    (
+        SELECT
+            COUNT(*)
+        FROM
+            follows
+            JOIN users ON users.id = follows.followee_id
+        WHERE
+            users.deleted_at IS NULL
+            AND follows.follower_id = @user_id
+    ) AS following_count,
+    (
+        SELECT
+            COUNT(*)
+        FROM
+            chirps
+        WHERE
+            chirps.user_id = @user_id
+    ) AS chirp_count;
+
+-- name: UpdateUserPassword :one
+UPDATE
+    users
+SET
+    hashed_password = $1,
+    updated_at = NOW()
+WHERE
+    id = $2
+RETURNING *;
+
+-- name: UpdateUserEmail :one
+UPDATE
+    users
+SET
+    email = $1,
+    email_verified_at = NOW(),
     updated_at = NOW()
 WHERE
     id = $2
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: MarkUserEmailVerified :one
+UPDATE
+    users
+SET
+    email_verified_at = COALESCE(email_verified_at, NOW()),
+    updated_at = NOW()
+WHERE
+    id = $1
+RETURNING *;
+
+-- name: ScheduleUserDeletion :exec
+UPDATE
+    users
+SET
+    deleted_at = NOW(),
+    updated_at = NOW()
+WHERE
+    id = $1;
+
+-- name: RestoreUser :one
+UPDATE
+    users
+SET
+    deleted_at = NULL,
+    updated_at = NOW()
+WHERE
+    id = $1
+RETURNING *;
+
+-- name: DeleteUser :exec
+DELETE FROM
+    users
+WHERE
+    id = $1;
+
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM
+    users
+WHERE
+    deleted_at < @deleted_before;
+
+-- name: IncrementUserTokenVersion :one
+UPDATE
+    users
+SET
+    token_version = token_version + 1,
+    updated_at = NOW()
+WHERE
+    id = $1
+RETURNING *;
+
+-- name: RehashUserPassword :exec
+UPDATE
+    users
+SET
+    hashed_password = @new_hashed_password
+WHERE
+    id = @id
+    AND hashed_password = @old_hashed_password;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Searches match against to_tsvector('english', body), so index that
+-- expression instead of storing the vector in a column read back with every
+-- chirp.
+CREATE INDEX idx_chirps_body_search ON chirps USING GIN (to_tsvector('english', body));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_chirps_body_search;
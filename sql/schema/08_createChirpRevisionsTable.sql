@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN edited_at TIMESTAMP NULL;
+
+CREATE TABLE chirp_revisions (
+    id UUID PRIMARY KEY,
+    chirp_id UUID NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_chirp_revisions_chirp_id_created_at ON chirp_revisions (chirp_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS chirp_revisions;
+ALTER TABLE chirps DROP COLUMN edited_at;
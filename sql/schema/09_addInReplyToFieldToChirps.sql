@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN in_reply_to UUID NULL REFERENCES chirps(id) ON DELETE SET NULL;
+ALTER TABLE chirps ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX idx_chirps_in_reply_to ON chirps (in_reply_to);
+
+-- reply_count is kept in step with the replies by a trigger, so it also stays
+-- correct when replies disappear through ON DELETE CASCADE.
+-- +goose StatementBegin
+CREATE FUNCTION update_chirp_reply_count() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' AND NEW.in_reply_to IS NOT NULL THEN
+        UPDATE chirps SET reply_count = reply_count + 1 WHERE id = NEW.in_reply_to;
+    ELSIF TG_OP = 'DELETE' AND OLD.in_reply_to IS NOT NULL THEN
+        UPDATE chirps SET reply_count = reply_count - 1 WHERE id = OLD.in_reply_to;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirps_reply_count
+AFTER INSERT OR DELETE ON chirps
+FOR EACH ROW EXECUTE FUNCTION update_chirp_reply_count();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS chirps_reply_count ON chirps;
+DROP FUNCTION IF EXISTS update_chirp_reply_count();
+DROP INDEX IF EXISTS idx_chirps_in_reply_to;
+ALTER TABLE chirps DROP COLUMN reply_count;
+ALTER TABLE chirps DROP COLUMN in_reply_to;
@@ -0,0 +1,37 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE chirp_likes (
+    chirp_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chirp_id, user_id),
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_chirp_likes_chirp_id_created_at ON chirp_likes (chirp_id, created_at);
+CREATE INDEX idx_chirp_likes_user_id ON chirp_likes (user_id);
+
+-- like_count is updated in the same transaction as the like row, and the
+-- row lock taken by the UPDATE serializes concurrent likes on one chirp.
+-- +goose StatementBegin
+CREATE FUNCTION update_chirp_like_count() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE chirps SET like_count = like_count + 1 WHERE id = NEW.chirp_id;
+    ELSIF TG_OP = 'DELETE' THEN
+        UPDATE chirps SET like_count = like_count - 1 WHERE id = OLD.chirp_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirp_likes_like_count
+AFTER INSERT OR DELETE ON chirp_likes
+FOR EACH ROW EXECUTE FUNCTION update_chirp_like_count();
+
+-- +goose Down
+DROP TABLE IF EXISTS chirp_likes;
+DROP FUNCTION IF EXISTS update_chirp_like_count();
+ALTER TABLE chirps DROP COLUMN like_count;
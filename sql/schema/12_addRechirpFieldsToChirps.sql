@@ -0,0 +1,16 @@
+-- +goose Up
+-- A rechirp disappears with its original, while a quote keeps its own body
+-- and only loses the reference. is_quote lets us tell a quote whose original
+-- was deleted apart from a plain chirp.
+ALTER TABLE chirps ADD COLUMN rechirp_of UUID NULL REFERENCES chirps(id) ON DELETE CASCADE;
+ALTER TABLE chirps ADD COLUMN quote_of UUID NULL REFERENCES chirps(id) ON DELETE SET NULL;
+ALTER TABLE chirps ADD COLUMN is_quote BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE UNIQUE INDEX idx_chirps_user_id_rechirp_of ON chirps (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL;
+CREATE INDEX idx_chirps_quote_of ON chirps (quote_of);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_chirps_quote_of;
+DROP INDEX IF EXISTS idx_chirps_user_id_rechirp_of;
+ALTER TABLE chirps DROP COLUMN is_quote;
+ALTER TABLE chirps DROP COLUMN quote_of;
+ALTER TABLE chirps DROP COLUMN rechirp_of;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Existing users get a random placeholder handle they can change later.
+ALTER TABLE users ADD COLUMN handle TEXT NOT NULL DEFAULT ('user_' || substr(md5(random()::text || clock_timestamp()::text), 1, 12));
+ALTER TABLE users ALTER COLUMN handle DROP DEFAULT;
+CREATE UNIQUE INDEX idx_users_handle_lower ON users (lower(handle));
+
+CREATE TABLE chirp_mentions (
+    chirp_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chirp_id, start_offset),
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_chirp_mentions_user_id ON chirp_mentions (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS chirp_mentions;
+DROP INDEX IF EXISTS idx_users_handle_lower;
+ALTER TABLE users DROP COLUMN handle;
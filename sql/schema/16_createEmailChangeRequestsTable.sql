@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE email_change_requests (
+    token_hash TEXT NOT NULL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    new_email TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    confirmed_at TIMESTAMP NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_email_change_requests_user_id ON email_change_requests (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS email_change_requests;
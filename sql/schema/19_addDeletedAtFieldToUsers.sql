@@ -0,0 +1,9 @@
+-- +goose Up
+-- Set when a user asks for their account to be deleted with a grace period.
+-- The row, and everything that cascades from it, is purged once it expires.
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP NULL;
+CREATE INDEX idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE users DROP COLUMN deleted_at;
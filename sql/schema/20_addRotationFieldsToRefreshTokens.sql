@@ -0,0 +1,14 @@
+-- +goose Up
+-- Every login starts a family of refresh tokens. Each refresh replaces the
+-- presented token with a new one in the same family, and presenting a
+-- replaced token again revokes the whole family.
+ALTER TABLE refresh_tokens ADD COLUMN family_id UUID NULL;
+UPDATE refresh_tokens SET family_id = gen_random_uuid();
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+ALTER TABLE refresh_tokens ADD COLUMN replaced_by TEXT NULL;
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+ALTER TABLE refresh_tokens DROP COLUMN replaced_by;
+ALTER TABLE refresh_tokens DROP COLUMN family_id;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Refresh tokens are stored as the hex SHA-256 digest of the token, so a copy
+-- of the table can't be used to log in. Existing rows are re-keyed in place
+-- and keep working.
+UPDATE refresh_tokens SET
+    token = encode(sha256(token::bytea), 'hex'),
+    replaced_by = encode(sha256(replaced_by::bytea), 'hex');
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+
+-- +goose Down
+-- Digests can't be turned back into tokens, so every session is dropped.
+DELETE FROM refresh_tokens;
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
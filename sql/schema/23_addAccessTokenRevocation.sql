@@ -0,0 +1,15 @@
+-- +goose Up
+-- Access tokens carry the version they were issued under; bumping it revokes
+-- all of a user's access tokens at once.
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
+
+-- Access tokens revoked one by one, kept until they would have expired.
+CREATE TABLE revoked_access_tokens (
+    jti TEXT NOT NULL PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_revoked_access_tokens_expires_at ON revoked_access_tokens (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS revoked_access_tokens;
+ALTER TABLE users DROP COLUMN token_version;
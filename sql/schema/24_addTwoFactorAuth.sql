@@ -0,0 +1,38 @@
+-- +goose Up
+-- A user's TOTP authenticator. It only counts once confirmed_at is set, i.e.
+-- the user proved the app is set up by entering a code from it. secret is
+-- encrypted with TOTP_ENCRYPTION_KEY and bound to user_id, see auth.SecretBox.
+CREATE TABLE totp_credentials (
+    user_id UUID NOT NULL PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    confirmed_at TIMESTAMP NULL,
+    -- Time step of the last accepted code, so a code can't be used twice.
+    last_used_step BIGINT NOT NULL DEFAULT 0
+);
+
+-- Single-use codes for logging in without the authenticator, stored as
+-- SHA-256 digests.
+CREATE TABLE recovery_codes (
+    code_hash TEXT NOT NULL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL
+);
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+-- Issued by a password login for a user with 2FA, exchanged together with a
+-- code for the real tokens.
+CREATE TABLE mfa_challenges (
+    token_hash TEXT NOT NULL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    used_at TIMESTAMP NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS mfa_challenges;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS totp_credentials;
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ZDSDD/Chirpy/internal/auth"
+	"github.com/ZDSDD/Chirpy/internal/database"
+	"github.com/ZDSDD/Chirpy/internal/mailer"
+	"github.com/google/uuid"
+)
+
+const emailChangeTTL = 24 * time.Hour
+
+// userPatch is a JSON merge patch (RFC 7396) against the current user.
+// Fields missing from the document are left untouched.
+type userPatch struct {
+	profileUpdate
+	Email           *string
+	Password        *string
+	CurrentPassword string
+}
+
+func decodeUserPatch(r *http.Request) (userPatch, error) {
+	var doc map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		return userPatch{}, errors.New("Body must be a JSON merge patch object")
+	}
+	var patch userPatch
+	for key, raw := range doc {
+		var err error
+		switch key {
+		case "handle":
+			patch.Handle, err = decodePatchString(key, raw, false)
+		case "display_name":
+			patch.DisplayName, err = decodePatchString(key, raw, true)
+		case "bio":
+			patch.Bio, err = decodePatchString(key, raw, true)
+		case "location":
+			patch.Location, err = decodePatchString(key, raw, true)
+		case "email":
+			patch.Email, err = decodePatchString(key, raw, false)
+		case "password":
+			patch.Password, err = decodePatchString(key, raw, false)
+		case "current_password":
+			err = json.Unmarshal(raw, &patch.CurrentPassword)
+		default:
+			err = fmt.Errorf("%s cannot be changed", key)
+		}
+		if err != nil {
+			return userPatch{}, err
+		}
+	}
+	return patch, nil
+}
+
+// decodePatchString reads one string member of a merge patch. A JSON null
+// clears the field, which is only allowed where clearable is set.
+func decodePatchString(key string, raw json.RawMessage, clearable bool) (*string, error) {
+	if string(raw) == "null" {
+		if !clearable {
+			return nil, fmt.Errorf("%s cannot be removed", key)
+		}
+		empty := ""
+		return &empty, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("%s must be a string", key)
+	}
+	if s == "" && !clearable {
+		return nil, fmt.Errorf("%s cannot be empty", key)
+	}
+	return &s, nil
+}
+
+// handlePatchUser applies a partial update to the current user. Changing the
+// password or email requires current_password, and a new email only takes
+// effect once confirmed through POST /api/users/email/confirm. A new
+// password signs out every session and personal access token, so the
+// response carries a fresh token and refresh token for the caller.
+func (cfg *apiConfig) handlePatchUser(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
+	patch, err := decodeUserPatch(r)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 400)
+		return
+	}
+	if err := patch.profileUpdate.validate(); err != nil {
+		responseWithJsonError(w, err.Error(), 400)
+		return
+	}
+	if patch.Email != nil && *patch.Email == user.Email {
+		patch.Email = nil
+	}
+	if patch.Email != nil && !isValidEmail(*patch.Email) {
+		responseWithJsonError(w, "Email is not a valid address", 400)
+		return
+	}
+	if patch.Email != nil || patch.Password != nil {
+		if patch.CurrentPassword == "" {
+			responseWithJsonError(w, "Current password is required to change email or password", 400)
+			return
+		}
+		if err := auth.CheckPasswordHash(patch.CurrentPassword, user.HashedPassword); err != nil {
+			responseWithJsonError(w, "Current password is incorrect", 403)
+			return
+		}
+	}
+	var hashedPasswd string
+	if patch.Password != nil {
+		email, handle, displayName := user.Email, user.Handle, user.DisplayName
+		if patch.Email != nil {
+			email = *patch.Email
+		}
+		if patch.Handle != nil {
+			handle = *patch.Handle
+		}
+		if patch.DisplayName != nil {
+			displayName = *patch.DisplayName
+		}
+		if !cfg.checkPassword(w, *patch.Password, email, handle, displayName) {
+			return
+		}
+		hashedPasswd, err = auth.HashPassword(*patch.Password)
+		if err != nil {
+			responseWithJsonError(w, err.Error(), 500)
+			return
+		}
+	}
+	var emailChangeToken, emailChangeTokenHash string
+	if patch.Email != nil {
+		emailChangeToken, emailChangeTokenHash, err = auth.MakeRefreshToken()
+		if err != nil {
+			responseWithJsonError(w, err.Error(), 500)
+			return
+		}
+	}
+
+	updatedUser := *user
+	var refreshToken string
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		if patch.Password != nil {
+			if _, err := q.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+				HashedPassword: hashedPasswd,
+				ID:             user.ID,
+			}); err != nil {
+				return err
+			}
+			// Everything signed in with the old password is signed out,
+			// including this session, which gets new tokens below.
+			updatedUser, err = q.IncrementUserTokenVersion(r.Context(), user.ID)
+			if err != nil {
+				return err
+			}
+			if err := q.RevokeUserRefreshTokens(r.Context(), user.ID); err != nil {
+				return err
+			}
+			if err := q.DeleteUserPersonalAccessTokens(r.Context(), user.ID); err != nil {
+				return err
+			}
+			refreshToken, err = createRefreshToken(r, q, user.ID, uuid.New())
+			if err != nil {
+				return err
+			}
+		}
+		if !patch.profileUpdate.isEmpty() {
+			updatedUser, err = q.UpdateUserProfile(r.Context(), patch.profileUpdate.params(user.ID))
+			if err != nil {
+				return err
+			}
+		}
+		if patch.Email != nil {
+			// Only the most recent request can be confirmed.
+			if err := q.DeletePendingEmailChangeRequests(r.Context(), user.ID); err != nil {
+				return err
+			}
+			_, err = q.CreateEmailChangeRequest(r.Context(), database.CreateEmailChangeRequestParams{
+				TokenHash: emailChangeTokenHash,
+				UserID:    user.ID,
+				NewEmail:  *patch.Email,
+				ExpiresAt: time.Now().Add(emailChangeTTL),
+			})
+		}
+		return err
+	})
+	if err != nil {
+		if isUniqueViolation(err, "idx_users_handle_lower") {
+			responseWithJsonError(w, "Handle is already taken", 409)
+			return
+		}
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	var token string
+	if patch.Password != nil {
+		token, err = cfg.makeAccessToken(&updatedUser)
+		if err != nil {
+			responseWithJsonError(w, err.Error(), 500)
+			return
+		}
+	}
+	resp := mapToJson(&updatedUser, token, refreshToken)
+	if patch.Email != nil {
+		err := cfg.mailer.Send(r.Context(), mailer.Message{
+			To:      *patch.Email,
+			Subject: "Confirm your new Chirpy email address",
+			Body: fmt.Sprintf("Someone asked to move the Chirpy account @%s to this address.\n\n"+
+				"Confirm the change by sending the token below to POST /api/users/email/confirm:\n\n%s\n\n"+
+				"The token expires in %s. If this wasn't you, ignore this email.\n", updatedUser.Handle, emailChangeToken, emailChangeTTL),
+		})
+		if err != nil {
+			log.Printf("Error sending email change confirmation to user %s: %s", user.ID, err)
+		}
+		resp.PendingEmail = *patch.Email
+	}
+	responseWithJson(resp, w, http.StatusOK)
+}
+
+func (cfg *apiConfig) handleConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	type confirmReqBody struct {
+		Token string `json:"token"`
+	}
+	var confirmReq confirmReqBody
+	json.NewDecoder(r.Body).Decode(&confirmReq)
+	if confirmReq.Token == "" {
+		responseWithJsonError(w, "Token is required", 400)
+		return
+	}
+	var user database.User
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		request, err := q.ConfirmEmailChangeRequest(r.Context(), auth.HashToken(confirmReq.Token))
+		if err != nil {
+			return err
+		}
+		user, err = q.UpdateUserEmail(r.Context(), database.UpdateUserEmailParams{
+			Email: request.NewEmail,
+			ID:    request.UserID,
+		})
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			responseWithJsonError(w, "Invalid or expired token", 400)
+		case isUniqueViolation(err, "users_email_key"):
+			responseWithJsonError(w, "Email is already in use", 409)
+		default:
+			responseWithJsonError(w, err.Error(), 500)
+		}
+		return
+	}
+	responseWithJson(mapToJson(&user, "", ""), w, http.StatusOK)
+}
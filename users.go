@@ -1,16 +1,25 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/ZDSDD/Chirpy/internal/auth"
 	"github.com/ZDSDD/Chirpy/internal/database"
 	"github.com/google/uuid"
-	passwordvalidator "github.com/wagslane/go-password-validator"
 )
 
+const refreshTokenTTL = 60 * 24 * time.Hour
+
 func (cfg *apiConfig) handleLogin(w http.ResponseWriter, r *http.Request) {
 	type UserReqBody struct {
 		Email    string `json:"email"`
@@ -26,29 +35,83 @@ func (cfg *apiConfig) handleLogin(w http.ResponseWriter, r *http.Request) {
 		responseWithJsonError(w, "Password is required", 400)
 		return
 	}
+	account, ip := strings.ToLower(userReq.Email), clientIP(r)
+	if !cfg.allowLogin(w, account, ip) {
+		return
+	}
 	user, err := cfg.db.GetUserByEmail(r.Context(), userReq.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		auth.CheckPasswordDummy(userReq.Password)
+		cfg.failLogin(w)
+		return
+	}
 	if err != nil {
+		cfg.loginErrored(account, ip)
 		responseWithJsonError(w, err.Error(), 500)
 		return
 	}
 
 	if err := auth.CheckPasswordHash(userReq.Password, user.HashedPassword); err != nil {
-		responseWithJsonError(w, "Invalid password", 401)
+		cfg.failLogin(w)
 		return
 	}
-
-	token, err := auth.MakeJWT(user.ID, cfg.jwtSecret, time.Hour)
-	refreshToken, err := auth.MakeRefreshToken()
+	cfg.loginSucceeded(account, ip)
+	cfg.rehashPassword(r.Context(), &user, userReq.Password)
+	hasTwoFactor, err := cfg.hasTwoFactor(r.Context(), user.ID)
 	if err != nil {
 		responseWithJsonError(w, err.Error(), 500)
 		return
 	}
-	_, err = cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		Token:     refreshToken,
-		UserID:    user.ID,
-		ExpiresAt: time.Now().Add(time.Hour * 24 * 60),
-	})
+	if hasTwoFactor {
+		cfg.startMFAChallenge(w, r, &user)
+		return
+	}
+	cfg.completeLogin(w, r, user)
+}
 
+// rehashPassword upgrades a password hash made with an older scheme or
+// weaker parameters, now that the login gave us the password. Failing to is
+// logged but doesn't fail the login.
+func (cfg *apiConfig) rehashPassword(ctx context.Context, user *database.User, password string) {
+	if !auth.PasswordNeedsRehash(user.HashedPassword) {
+		return
+	}
+	hashedPasswd, err := auth.HashPassword(password)
+	if err == nil {
+		// Matching the old hash keeps this from undoing a concurrent
+		// password change.
+		err = cfg.db.RehashUserPassword(ctx, database.RehashUserPasswordParams{
+			NewHashedPassword: hashedPasswd,
+			ID:                user.ID,
+			OldHashedPassword: user.HashedPassword,
+		})
+	}
+	if err != nil {
+		log.Printf("Error rehashing password for user %s: %s", user.ID, err)
+		return
+	}
+	user.HashedPassword = hashedPasswd
+}
+
+// completeLogin issues tokens to a user who has passed every login check.
+func (cfg *apiConfig) completeLogin(w http.ResponseWriter, r *http.Request, user database.User) {
+	var err error
+	// Logging in during the deletion grace period cancels the deletion.
+	if user.DeletedAt.Valid {
+		user, err = cfg.db.RestoreUser(r.Context(), user.ID)
+		if err != nil {
+			responseWithJsonError(w, err.Error(), 500)
+			return
+		}
+	}
+
+	token, err := cfg.makeAccessToken(&user)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	// Each login starts a new refresh token family.
+	refreshToken, err := createRefreshToken(r, cfg.db, user.ID, uuid.New())
 	if err != nil {
 		responseWithJsonError(w, err.Error(), 500)
 		return
@@ -57,10 +120,19 @@ func (cfg *apiConfig) handleLogin(w http.ResponseWriter, r *http.Request) {
 	responseWithJson(mapToJson(&user, token, refreshToken), w, http.StatusOK)
 }
 
-func (cfg *apiConfig) handleRefreshToken(w http.ResponseWriter, r *http.Request, refreshToken string, user *database.User) {
-	rtdb, err := cfg.db.GetRefreshToken(r.Context(), refreshToken)
+// handleRefreshToken trades a refresh token for a new access token and a new
+// refresh token in the same family. A refresh token can only be used once:
+// presenting one that was already replaced means it has leaked, so the whole
+// family is revoked and both holders have to log in again.
+func (cfg *apiConfig) handleRefreshToken(w http.ResponseWriter, r *http.Request, refreshToken string) {
+	rtdb, err := cfg.db.GetRefreshToken(r.Context(), auth.HashToken(refreshToken))
 	if err != nil {
-		responseWithJsonError(w, err.Error(), 401)
+		responseWithJsonError(w, "Invalid refresh token", 401)
+		return
+	}
+	if rtdb.ReplacedBy.Valid {
+		cfg.revokeReusedRefreshToken(r.Context(), rtdb)
+		responseWithJsonError(w, "Refresh token reuse detected", 401)
 		return
 	}
 	if rtdb.ExpiresAt.Before(time.Now()) {
@@ -71,12 +143,68 @@ func (cfg *apiConfig) handleRefreshToken(w http.ResponseWriter, r *http.Request,
 		responseWithJsonError(w, "Refresh token revoked", 401)
 		return
 	}
-	token, err := auth.MakeJWT(user.ID, cfg.jwtSecret, time.Hour)
+
+	var newRefreshToken string
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		newRefreshToken, err = createRefreshToken(r, q, rtdb.UserID, rtdb.FamilyID)
+		if err != nil {
+			return err
+		}
+		// Claiming the old token fails if another request rotated it first.
+		_, err = q.RotateRefreshToken(r.Context(), database.RotateRefreshTokenParams{
+			ReplacedBy: sql.NullString{String: auth.HashToken(newRefreshToken), Valid: true},
+			TokenHash:  rtdb.TokenHash,
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			cfg.revokeReusedRefreshToken(r.Context(), rtdb)
+			responseWithJsonError(w, "Refresh token reuse detected", 401)
+			return
+		}
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	user, err := cfg.db.GetUserById(r.Context(), rtdb.UserID)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
+	token, err := cfg.makeAccessToken(&user)
 	if err != nil {
 		responseWithJsonError(w, err.Error(), 500)
 		return
 	}
-	responseWithJson(map[string]string{"token": token}, w, http.StatusOK)
+	responseWithJson(map[string]string{
+		"token":         token,
+		"refresh_token": newRefreshToken,
+	}, w, http.StatusOK)
+}
+
+func (cfg *apiConfig) revokeReusedRefreshToken(ctx context.Context, rtdb database.RefreshToken) {
+	log.Printf("Refresh token reuse for user %s, revoking family %s", rtdb.UserID, rtdb.FamilyID)
+	if err := cfg.db.RevokeRefreshTokenFamily(ctx, rtdb.FamilyID); err != nil {
+		log.Printf("Error revoking refresh token family %s: %s", rtdb.FamilyID, err)
+	}
+}
+
+// createRefreshToken stores a new refresh token in the given family, noting
+// the client that asked for it so it shows up in the sessions list.
+func createRefreshToken(r *http.Request, q *database.Queries, userID, familyID uuid.UUID) (string, error) {
+	refreshToken, tokenHash, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	_, err = q.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+		TokenHash: tokenHash,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+		FamilyID:  familyID,
+		UserAgent: r.UserAgent(),
+		Ip:        clientIP(r),
+	})
+	return refreshToken, err
 }
 
 func (cfg *apiConfig) requireBearerToken(next func(w http.ResponseWriter, r *http.Request, token string)) http.HandlerFunc {
@@ -94,29 +222,85 @@ func (cfg *apiConfig) requireBearerToken(next func(w http.ResponseWriter, r *htt
 	}
 }
 
-// 2. Validate the JWT token and load the associated user
-func (cfg *apiConfig) requireValidJWTToken(next func(w http.ResponseWriter, r *http.Request, token string, user *database.User)) func(w http.ResponseWriter, r *http.Request, token string) {
+// authError is why authenticateToken turned a token down, as the status and
+// message to answer with. Any other error means the check itself failed.
+type authError struct {
+	status int
+	msg    string
+}
+
+func (e *authError) Error() string {
+	return e.msg
+}
+
+// authenticateToken loads the user behind a bearer token. Personal access
+// tokens have to have been granted scope; JWTs from a login can do anything
+// as long as they haven't been revoked.
+func (cfg *apiConfig) authenticateToken(ctx context.Context, token, scope string) (*database.User, error) {
+	if auth.IsPersonalAccessToken(token) {
+		return cfg.authenticatePersonalAccessToken(ctx, token, scope)
+	}
+	claims, err := cfg.jwtKeys.ParseAccessToken(token)
+	if err != nil {
+		return nil, &authError{http.StatusUnauthorized, err.Error()}
+	}
+	denied, err := cfg.denylist.IsDenied(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if denied {
+		return nil, &authError{http.StatusUnauthorized, "Token has been revoked"}
+	}
+	user, err := cfg.db.GetUserById(ctx, claims.UserID())
+	if err != nil {
+		return nil, &authError{http.StatusUnauthorized, "User not found"}
+	}
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, &authError{http.StatusUnauthorized, "Token has been revoked"}
+	}
+	if user.DeletedAt.Valid {
+		return nil, &authError{http.StatusUnauthorized, "Account is scheduled for deletion, log in again to restore it"}
+	}
+	return &user, nil
+}
+
+// requireValidJWTToken authenticates the bearer token and loads its user.
+// Personal access tokens are accepted too if they were granted scope; JWTs
+// from a login can do anything.
+func (cfg *apiConfig) requireValidJWTToken(scope string, next func(w http.ResponseWriter, r *http.Request, token string, user *database.User)) func(w http.ResponseWriter, r *http.Request, token string) {
 	return func(w http.ResponseWriter, r *http.Request, token string) {
-		userId, err := auth.ValidateJWT(token, cfg.jwtSecret) // Validate JWT token
-		if err != nil {
-			responseWithJsonError(w, err.Error(), http.StatusUnauthorized)
+		user, err := cfg.authenticateToken(r.Context(), token, scope)
+		var authErr *authError
+		if errors.As(err, &authErr) {
+			responseWithJsonError(w, authErr.msg, authErr.status)
 			return
 		}
-
-		// Retrieve user from database using the userId extracted from the token
-		user, err := cfg.db.GetUserById(r.Context(), userId)
 		if err != nil {
-			responseWithJsonError(w, "User not found", http.StatusUnauthorized)
+			responseWithJsonError(w, err.Error(), 500)
 			return
 		}
+		next(w, r, token, user)
+	}
+}
 
-		// Call the next function with token and user
-		next(w, r, token, &user)
+// viewerID returns the user behind an optional bearer token, for public
+// routes whose response changes for a signed-in user. A token that wouldn't
+// pass requireValidJWTToken counts as no token.
+func (cfg *apiConfig) viewerID(r *http.Request) (uuid.UUID, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+	user, err := cfg.authenticateToken(r.Context(), token, scopeChirpsRead)
+	if err != nil {
+		return uuid.UUID{}, false
 	}
+	return user.ID, true
 }
+
 func (cfg *apiConfig) handleRevokeToken(w http.ResponseWriter, r *http.Request, refreshToken string) {
 
-	err := cfg.db.RevokeRefreshToken(r.Context(), refreshToken)
+	err := cfg.db.RevokeRefreshToken(r.Context(), auth.HashToken(refreshToken))
 	if err != nil {
 		responseWithJsonError(w, err.Error(), 500)
 		return
@@ -127,6 +311,7 @@ func (cfg *apiConfig) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 	type UserReqBody struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Handle   string `json:"handle"`
 	}
 
 	var userReq UserReqBody
@@ -135,88 +320,144 @@ func (cfg *apiConfig) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 		responseWithJsonError(w, "Email is required", 400)
 		return
 	}
+	if !isValidEmail(userReq.Email) {
+		responseWithJsonError(w, "Email is not a valid address", 400)
+		return
+	}
 	if userReq.Password == "" {
 		responseWithJsonError(w, "Password is required", 400)
 		return
 	}
-	const minEntropy = 1
-	if err := passwordvalidator.Validate(userReq.Password, minEntropy); err != nil {
+	handle := userReq.Handle
+	if handle == "" {
+		handle = defaultHandle()
+	} else if err := validateHandle(handle); err != nil {
 		responseWithJsonError(w, err.Error(), 400)
+		return
+	}
+	if !cfg.checkPassword(w, userReq.Password, userReq.Email, userReq.Handle) {
+		return
 	}
 	hashedPasswd, err := auth.HashPassword(userReq.Password)
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return
+	}
 
 	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
 		Email:          userReq.Email,
 		HashedPassword: hashedPasswd,
+		Handle:         handle,
 	})
 	if err != nil {
+		if isUniqueViolation(err, "idx_users_handle_lower") {
+			responseWithJsonError(w, "Handle is already taken", 409)
+			return
+		}
 		responseWithJsonError(w, err.Error(), 500)
 		return
 	}
+	// The account exists either way; a lost email can be resent.
+	if err := cfg.sendEmailVerification(r.Context(), &user); err != nil {
+		log.Printf("Error sending verification email to user %s: %s", user.ID, err)
+	}
 	responseWithJson(mapToJson(&user, "", ""), w, http.StatusCreated)
 }
 
 type UserResponseLogin struct {
-	ID           uuid.UUID `json:"id"`
-	Email        string    `json:"email"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Token        string    `json:"token,omitempty"`
-	RefreshToken string    `json:"refresh_token,omitempty"`
-	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	ID            uuid.UUID `json:"id"`
+	Email         string    `json:"email"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Token         string    `json:"token,omitempty"`
+	RefreshToken  string    `json:"refresh_token,omitempty"`
+	IsChirpyRed   bool      `json:"is_chirpy_red"`
+	Handle        string    `json:"handle"`
+	DisplayName   string    `json:"display_name"`
+	Bio           string    `json:"bio"`
+	Location      string    `json:"location"`
+	PendingEmail  string    `json:"pending_email,omitempty"`
+	EmailVerified bool      `json:"email_verified"`
 }
 
 func mapToJson(du *database.User, token string, refreshToken string) UserResponseLogin {
 	return UserResponseLogin{
-		ID:           du.ID,
-		Email:        du.Email,
-		CreatedAt:    du.CreatedAt,
-		UpdatedAt:    du.UpdatedAt,
-		Token:        token,
-		RefreshToken: refreshToken,
-		IsChirpyRed:  du.IsChirpyRed,
+		ID:            du.ID,
+		Email:         du.Email,
+		CreatedAt:     du.CreatedAt,
+		UpdatedAt:     du.UpdatedAt,
+		Token:         token,
+		RefreshToken:  refreshToken,
+		IsChirpyRed:   du.IsChirpyRed,
+		Handle:        du.Handle,
+		DisplayName:   du.DisplayName,
+		Bio:           du.Bio,
+		Location:      du.Location,
+		EmailVerified: du.EmailVerifiedAt.Valid,
+	}
+}
+
+// checkPassword answers 400 with the reason, and returns false, when password
+// breaks cfg.passwordPolicy. personal is what the user is known by, such as
+// their email and handle.
+func (cfg *apiConfig) checkPassword(w http.ResponseWriter, password string, personal ...string) bool {
+	err := cfg.passwordPolicy.Check(password, personal...)
+	var policyErr *auth.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		responseWithJsonError(w, policyErr.Reason, 400)
+		return false
+	}
+	if err != nil {
+		responseWithJsonError(w, err.Error(), 500)
+		return false
 	}
+	return true
+}
+
+// isValidEmail reports whether email is a bare address such as
+// "user@example.com", without a display name or angle brackets.
+func isValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
 }
 
-func (cfg *apiConfig) handleUpdateUser(w http.ResponseWriter, r *http.Request, token string) {
+// defaultHandle makes a placeholder handle for users who sign up without
+// choosing one.
+func defaultHandle() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return "user_" + hex.EncodeToString(b)
+}
+
+func (cfg *apiConfig) handleUpdateUser(w http.ResponseWriter, r *http.Request, _ string, user *database.User) {
 	type UserReqBody struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		profileUpdate
 	}
 	var userReq UserReqBody
 	json.NewDecoder(r.Body).Decode(&userReq)
-	if userReq.Email == "" {
-		responseWithJsonError(w, "Email is required", 400)
+	// Email and password changes need the current password and, for email, a
+	// confirmation, which only PATCH does.
+	if userReq.Email != "" || userReq.Password != "" {
+		responseWithJsonError(w, "Email and password can only be changed with PATCH /api/users", 400)
 		return
 	}
-	if userReq.Password == "" {
-		responseWithJsonError(w, "Password is required", 400)
+	if userReq.profileUpdate.isEmpty() {
+		responseWithJsonError(w, "Nothing to update", 400)
 		return
 	}
-
-	userId, err := auth.ValidateJWT(token, cfg.jwtSecret)
-	user, err := cfg.db.GetUserById(r.Context(), userId)
-	if err != nil {
-		responseWithJsonError(w, err.Error(), 401)
-		return
-	}
-
-	if userId != user.ID {
-		responseWithJsonError(w, "Unauthorized", 401)
-		return
-	}
-	const minEntropy = 1
-	if err := passwordvalidator.Validate(userReq.Password, minEntropy); err != nil {
+	if err := userReq.profileUpdate.validate(); err != nil {
 		responseWithJsonError(w, err.Error(), 400)
+		return
 	}
-	hashedPasswd, err := auth.HashPassword(userReq.Password)
 
-	updatedUser, err := cfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
-		Email:          userReq.Email,
-		HashedPassword: hashedPasswd,
-		ID:             user.ID,
-	})
+	updatedUser, err := cfg.db.UpdateUserProfile(r.Context(), userReq.profileUpdate.params(user.ID))
 	if err != nil {
+		if isUniqueViolation(err, "idx_users_handle_lower") {
+			responseWithJsonError(w, "Handle is already taken", 409)
+			return
+		}
 		responseWithJsonError(w, err.Error(), 500)
 		return
 	}